@@ -0,0 +1,3 @@
+ALTER TABLE teams
+    ADD COLUMN reviewer_strategy TEXT NOT NULL DEFAULT 'LEAST_LOADED'
+    CHECK (reviewer_strategy IN ('RANDOM','ROUND_ROBIN','LEAST_LOADED','WEIGHTED'));
@@ -0,0 +1,3 @@
+ALTER TABLE teams
+    ADD COLUMN reviewers_required INT NOT NULL DEFAULT 2
+    CHECK (reviewers_required > 0);
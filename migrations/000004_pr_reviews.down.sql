@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS pr_reviews;
+ALTER TABLE teams DROP COLUMN IF EXISTS approvals_required;
@@ -0,0 +1,11 @@
+ALTER TABLE teams
+    ADD COLUMN approvals_required INT NOT NULL DEFAULT 0
+    CHECK (approvals_required >= 0);
+
+CREATE TABLE pr_reviews (
+    pr_id      TEXT NOT NULL REFERENCES prs(id) ON DELETE CASCADE,
+    user_id    TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    state      TEXT NOT NULL CHECK (state IN ('PENDING','APPROVED','CHANGES_REQUESTED','COMMENTED')),
+    updated_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (pr_id, user_id)
+);
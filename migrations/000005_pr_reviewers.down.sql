@@ -0,0 +1,24 @@
+ALTER TABLE prs ADD COLUMN reviewers TEXT[] NOT NULL DEFAULT '{}';
+
+UPDATE prs p
+SET reviewers = r.reviewers
+FROM (
+    SELECT pr_id, array_agg(user_id ORDER BY assigned_at, user_id) AS reviewers
+    FROM pr_reviewers
+    GROUP BY pr_id
+) r
+WHERE r.pr_id = p.id;
+
+CREATE TABLE pr_reviews (
+    pr_id      TEXT NOT NULL REFERENCES prs(id) ON DELETE CASCADE,
+    user_id    TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    state      TEXT NOT NULL CHECK (state IN ('PENDING','APPROVED','CHANGES_REQUESTED','COMMENTED')),
+    updated_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (pr_id, user_id)
+);
+
+INSERT INTO pr_reviews (pr_id, user_id, state)
+SELECT pr_id, user_id, state FROM pr_reviewers WHERE state <> 'PENDING';
+
+DROP INDEX IF EXISTS prs_author_id_idx;
+DROP TABLE IF EXISTS pr_reviewers;
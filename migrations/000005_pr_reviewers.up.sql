@@ -0,0 +1,22 @@
+CREATE TABLE pr_reviewers (
+    pr_id       TEXT NOT NULL REFERENCES prs(id) ON DELETE CASCADE,
+    user_id     TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    assigned_at TIMESTAMP NOT NULL DEFAULT now(),
+    state       TEXT NOT NULL DEFAULT 'PENDING'
+                CHECK (state IN ('PENDING','APPROVED','CHANGES_REQUESTED','COMMENTED')),
+    PRIMARY KEY (pr_id, user_id)
+);
+
+CREATE INDEX pr_reviewers_user_id_idx ON pr_reviewers (user_id, pr_id);
+CREATE INDEX prs_author_id_idx ON prs (author_id);
+
+INSERT INTO pr_reviewers (pr_id, user_id, assigned_at, state)
+SELECT p.id, r.user_id, p.created_at, COALESCE(rv.state, 'PENDING')
+FROM prs p
+CROSS JOIN LATERAL unnest(p.reviewers) AS r(user_id)
+JOIN users u ON u.id = r.user_id
+LEFT JOIN pr_reviews rv ON rv.pr_id = p.id AND rv.user_id = r.user_id
+ON CONFLICT DO NOTHING;
+
+DROP TABLE pr_reviews;
+ALTER TABLE prs DROP COLUMN reviewers;
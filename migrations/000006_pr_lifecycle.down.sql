@@ -0,0 +1,9 @@
+UPDATE prs SET status = 'OPEN' WHERE status IN ('CLOSED','DRAFT');
+
+ALTER TABLE prs DROP CONSTRAINT IF EXISTS prs_status_check;
+ALTER TABLE prs
+    ADD CONSTRAINT prs_status_check
+    CHECK (status IN ('OPEN','MERGED'));
+
+ALTER TABLE prs DROP COLUMN IF EXISTS closed_at;
+ALTER TABLE prs DROP COLUMN IF EXISTS reviewers_count;
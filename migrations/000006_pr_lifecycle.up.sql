@@ -0,0 +1,7 @@
+ALTER TABLE prs DROP CONSTRAINT IF EXISTS prs_status_check;
+ALTER TABLE prs
+    ADD CONSTRAINT prs_status_check
+    CHECK (status IN ('OPEN','MERGED','CLOSED','DRAFT'));
+
+ALTER TABLE prs ADD COLUMN reviewers_count INT CHECK (reviewers_count > 0);
+ALTER TABLE prs ADD COLUMN closed_at TIMESTAMP;
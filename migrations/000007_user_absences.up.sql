@@ -0,0 +1,11 @@
+CREATE TABLE user_absences (
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    starts_at     TIMESTAMPTZ NOT NULL,
+    ends_at       TIMESTAMPTZ NOT NULL,
+    hand_off      BOOLEAN NOT NULL DEFAULT FALSE,
+    handed_off_at TIMESTAMPTZ,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX user_absences_user_id_idx ON user_absences (user_id, ends_at);
@@ -0,0 +1,9 @@
+ALTER TABLE prs DROP CONSTRAINT prs_author_id_fkey;
+ALTER TABLE prs ADD CONSTRAINT prs_author_id_fkey
+    FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE;
+
+DELETE FROM users WHERE team_name IS NULL;
+ALTER TABLE users DROP CONSTRAINT users_team_name_fkey;
+ALTER TABLE users ADD CONSTRAINT users_team_name_fkey
+    FOREIGN KEY (team_name) REFERENCES teams(name) ON DELETE CASCADE;
+ALTER TABLE users ALTER COLUMN team_name SET NOT NULL;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS prs_created_at_idx;
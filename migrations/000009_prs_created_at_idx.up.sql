@@ -0,0 +1 @@
+CREATE INDEX prs_created_at_idx ON prs (created_at, id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS pr_reviewers_assigned_at_idx;
+DROP TABLE IF EXISTS pr_reassignments;
@@ -0,0 +1,10 @@
+CREATE TABLE pr_reassignments (
+    id            BIGSERIAL PRIMARY KEY,
+    pr_id         TEXT NOT NULL REFERENCES prs(id) ON DELETE CASCADE,
+    old_user_id   TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    new_user_id   TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reassigned_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX pr_reassignments_old_user_id_idx ON pr_reassignments (old_user_id, reassigned_at);
+CREATE INDEX pr_reviewers_assigned_at_idx ON pr_reviewers (assigned_at);
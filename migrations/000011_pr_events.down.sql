@@ -0,0 +1,18 @@
+CREATE TABLE pr_reassignments (
+    id            BIGSERIAL PRIMARY KEY,
+    pr_id         TEXT NOT NULL REFERENCES prs(id) ON DELETE CASCADE,
+    old_user_id   TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    new_user_id   TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reassigned_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX pr_reassignments_old_user_id_idx ON pr_reassignments (old_user_id, reassigned_at);
+
+INSERT INTO pr_reassignments (pr_id, old_user_id, new_user_id, reassigned_at)
+SELECT e.pr_id, e.old_user_id, e.new_user_id, e.created_at
+FROM pr_events e
+WHERE e.type = 'REASSIGNED'
+AND EXISTS (SELECT 1 FROM users WHERE id = e.old_user_id)
+AND EXISTS (SELECT 1 FROM users WHERE id = e.new_user_id);
+
+DROP TABLE IF EXISTS pr_events;
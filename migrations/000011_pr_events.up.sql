@@ -0,0 +1,34 @@
+CREATE TABLE pr_events (
+    id           BIGSERIAL PRIMARY KEY,
+    pr_id        TEXT NOT NULL REFERENCES prs(id) ON DELETE CASCADE,
+    type         TEXT NOT NULL
+                 CHECK (type IN ('CREATED','ASSIGNED','REASSIGNED','REVIEWED','MERGED','STATUS_CHANGED')),
+    actor_id     TEXT,
+    old_user_id  TEXT,
+    new_user_id  TEXT,
+    old_status   TEXT,
+    new_status   TEXT,
+    review_state TEXT,
+    reason       TEXT,
+    created_at   TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX pr_events_pr_id_idx ON pr_events (pr_id, id);
+CREATE INDEX pr_events_reassigned_idx ON pr_events (old_user_id, created_at)
+    WHERE type = 'REASSIGNED';
+
+INSERT INTO pr_events (pr_id, type, actor_id, new_status, created_at)
+SELECT id, 'CREATED', author_id,
+       CASE WHEN status = 'DRAFT' THEN 'DRAFT' ELSE 'OPEN' END, created_at
+FROM prs;
+
+INSERT INTO pr_events (pr_id, type, new_user_id, created_at)
+SELECT pr_id, 'ASSIGNED', user_id, assigned_at FROM pr_reviewers;
+
+INSERT INTO pr_events (pr_id, type, old_user_id, new_user_id, created_at)
+SELECT pr_id, 'REASSIGNED', old_user_id, new_user_id, reassigned_at FROM pr_reassignments;
+
+INSERT INTO pr_events (pr_id, type, old_status, new_status, created_at)
+SELECT id, 'MERGED', 'OPEN', 'MERGED', merged_at FROM prs WHERE status = 'MERGED';
+
+DROP TABLE pr_reassignments;
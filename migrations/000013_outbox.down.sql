@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS webhook_deliveries_event_idx;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS event_id;
+
+DROP TABLE IF EXISTS outbox;
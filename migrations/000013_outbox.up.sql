@@ -0,0 +1,17 @@
+CREATE TABLE outbox (
+    id              BIGSERIAL PRIMARY KEY,
+    event_id        UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    topic           TEXT NOT NULL,
+    payload         JSONB NOT NULL,
+    attempts        INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    dispatched_at   TIMESTAMPTZ,
+    last_error      TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX outbox_due_idx ON outbox (next_attempt_at)
+    WHERE dispatched_at IS NULL;
+
+ALTER TABLE webhook_deliveries ADD COLUMN event_id UUID;
+CREATE UNIQUE INDEX webhook_deliveries_event_idx ON webhook_deliveries (webhook_id, event_id);
@@ -0,0 +1,5 @@
+ALTER TABLE prs
+    DROP COLUMN IF EXISTS changed_files,
+    DROP COLUMN IF EXISTS repository;
+
+DROP TABLE IF EXISTS codeowners;
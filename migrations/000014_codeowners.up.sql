@@ -0,0 +1,9 @@
+CREATE TABLE codeowners (
+    repository TEXT PRIMARY KEY,
+    content    TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE prs
+    ADD COLUMN repository    TEXT,
+    ADD COLUMN changed_files TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS prs_repository_idx;
+ALTER TABLE prs DROP CONSTRAINT IF EXISTS prs_repository_fkey;
+ALTER TABLE codeowners DROP CONSTRAINT IF EXISTS codeowners_repository_fkey;
+
+DROP TABLE IF EXISTS repository_reviewers;
+DROP TABLE IF EXISTS repository_teams;
+DROP TABLE IF EXISTS repositories;
@@ -0,0 +1,32 @@
+CREATE TABLE repositories (
+    name               TEXT PRIMARY KEY,
+    reviewers_required INT CHECK (reviewers_required > 0),
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE repository_teams (
+    repository TEXT NOT NULL REFERENCES repositories(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    team_name  TEXT NOT NULL REFERENCES teams(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    PRIMARY KEY (repository, team_name)
+);
+
+CREATE TABLE repository_reviewers (
+    repository TEXT NOT NULL REFERENCES repositories(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    user_id    TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (repository, user_id)
+);
+
+INSERT INTO repositories (name)
+SELECT repository FROM codeowners
+UNION
+SELECT repository FROM prs WHERE repository IS NOT NULL;
+
+ALTER TABLE codeowners
+    ADD CONSTRAINT codeowners_repository_fkey
+    FOREIGN KEY (repository) REFERENCES repositories(name) ON UPDATE CASCADE ON DELETE CASCADE;
+
+ALTER TABLE prs
+    ADD CONSTRAINT prs_repository_fkey
+    FOREIGN KEY (repository) REFERENCES repositories(name) ON UPDATE CASCADE;
+
+CREATE INDEX prs_repository_idx ON prs (repository);
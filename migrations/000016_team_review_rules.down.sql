@@ -0,0 +1,3 @@
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS rule_team;
+
+DROP TABLE IF EXISTS team_review_rules;
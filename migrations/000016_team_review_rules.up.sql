@@ -0,0 +1,10 @@
+CREATE TABLE team_review_rules (
+    team_name     TEXT NOT NULL REFERENCES teams(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    required_team TEXT NOT NULL REFERENCES teams(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    reviewers     INT NOT NULL CHECK (reviewers > 0),
+    PRIMARY KEY (team_name, required_team),
+    CHECK (team_name <> required_team)
+);
+
+ALTER TABLE pr_reviewers
+    ADD COLUMN rule_team TEXT REFERENCES teams(name) ON UPDATE CASCADE ON DELETE SET NULL;
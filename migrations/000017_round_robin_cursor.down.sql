@@ -0,0 +1,2 @@
+ALTER TABLE repositories DROP COLUMN IF EXISTS round_robin_last;
+ALTER TABLE teams DROP COLUMN IF EXISTS round_robin_last;
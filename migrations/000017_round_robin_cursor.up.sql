@@ -0,0 +1,2 @@
+ALTER TABLE teams ADD COLUMN round_robin_last TEXT;
+ALTER TABLE repositories ADD COLUMN round_robin_last TEXT;
@@ -0,0 +1 @@
+ALTER TABLE prs DROP COLUMN IF EXISTS approvals_required;
@@ -0,0 +1,9 @@
+ALTER TABLE prs
+    ADD COLUMN approvals_required INT NOT NULL DEFAULT 0
+    CHECK (approvals_required >= 0);
+
+UPDATE prs p
+SET approvals_required = t.approvals_required
+FROM users u
+JOIN teams t ON t.name = u.team_name
+WHERE u.id = p.author_id;
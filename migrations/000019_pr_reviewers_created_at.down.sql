@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS pr_reviewers_user_created_idx;
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS pr_created_at;
@@ -0,0 +1,10 @@
+ALTER TABLE pr_reviewers ADD COLUMN pr_created_at TIMESTAMP;
+
+UPDATE pr_reviewers r
+SET pr_created_at = p.created_at
+FROM prs p
+WHERE p.id = r.pr_id;
+
+ALTER TABLE pr_reviewers ALTER COLUMN pr_created_at SET NOT NULL;
+
+CREATE INDEX pr_reviewers_user_created_idx ON pr_reviewers (user_id, pr_created_at DESC, pr_id DESC);
@@ -0,0 +1,5 @@
+DELETE FROM prs WHERE author_id IS NULL;
+ALTER TABLE prs DROP CONSTRAINT prs_author_id_fkey;
+ALTER TABLE prs ADD CONSTRAINT prs_author_id_fkey
+    FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE RESTRICT;
+ALTER TABLE prs ALTER COLUMN author_id SET NOT NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE prs ALTER COLUMN author_id DROP NOT NULL;
+ALTER TABLE prs DROP CONSTRAINT prs_author_id_fkey;
+ALTER TABLE prs ADD CONSTRAINT prs_author_id_fkey
+    FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE SET NULL;
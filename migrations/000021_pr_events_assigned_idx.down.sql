@@ -0,0 +1 @@
+DROP INDEX IF EXISTS pr_events_assigned_idx;
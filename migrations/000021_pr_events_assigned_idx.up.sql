@@ -0,0 +1,2 @@
+CREATE INDEX pr_events_assigned_idx ON pr_events (new_user_id, created_at)
+    WHERE type IN ('ASSIGNED', 'REASSIGNED');
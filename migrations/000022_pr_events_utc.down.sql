@@ -0,0 +1,3 @@
+ALTER TABLE pr_events ALTER COLUMN created_at SET DEFAULT now();
+UPDATE pr_events
+SET created_at = created_at AT TIME ZONE 'UTC' AT TIME ZONE current_setting('TimeZone');
@@ -0,0 +1,5 @@
+-- pr_events.created_at holds UTC wall time; rows written so far hold the
+-- wall time of the session zone, which is the server default
+UPDATE pr_events
+SET created_at = created_at AT TIME ZONE current_setting('TimeZone') AT TIME ZONE 'UTC';
+ALTER TABLE pr_events ALTER COLUMN created_at SET DEFAULT (now() AT TIME ZONE 'UTC');
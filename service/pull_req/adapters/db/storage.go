@@ -1,18 +1,20 @@
 package db
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"log/slog"
 	"pull_req/pull_req/core"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
-	"github.com/lib/pq"
 )
 
 const (
@@ -38,6 +40,40 @@ func NewDB(log *slog.Logger, address string) (*DB, error) {
 	}, nil
 }
 
+type txKey struct{}
+
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error)
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+}
+
+func (d *DB) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (d *DB) q(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return d.conn
+}
+
 type TeamDB struct {
 	db *DB
 }
@@ -53,17 +89,17 @@ type UserInsert struct {
 	TeamName string `db:"team_name"`
 }
 
-func (t *TeamDB) Add(ctx context.Context, team core.Team) error {
-	tx, err := t.db.conn.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	_, err = tx.ExecContext(
+func (t *TeamDB) Add(ctx context.Context, team core.Team, moveMembers bool) error {
+	return t.db.WithinTx(ctx, func(ctx context.Context) error {
+		return t.add(ctx, team, moveMembers)
+	})
+}
+func (t *TeamDB) add(ctx context.Context, team core.Team, moveMembers bool) error {
+	_, err := t.db.q(ctx).ExecContext(
 		ctx,
-		`INSERT INTO teams VALUES($1)`,
-		team.Name,
+		`INSERT INTO teams(name, reviewer_strategy, reviewers_required, approvals_required)
+		 VALUES($1, $2, $3, $4)`,
+		team.Name, team.ReviewerStrategy, team.ReviewersRequired, team.ApprovalsRequired,
 	)
 	if err != nil {
 		var pgErr *pgconn.PgError
@@ -73,6 +109,28 @@ func (t *TeamDB) Add(ctx context.Context, team core.Team) error {
 		return err
 	}
 
+	if len(team.Members) > 0 && !moveMembers {
+		ids := make([]string, len(team.Members))
+		for i, member := range team.Members {
+			ids[i] = member.ID
+		}
+		var taken bool
+		err = t.db.q(ctx).GetContext(
+			ctx,
+			&taken,
+			`SELECT EXISTS (
+			     SELECT 1 FROM users WHERE id = ANY($1) AND team_name <> $2
+			 )`,
+			ids, team.Name,
+		)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return core.ErrMemberOfOtherTeam
+		}
+	}
+
 	if len(team.Members) > 0 {
 		insertUsers := make([]UserInsert, len(team.Members))
 		for i, member := range team.Members {
@@ -84,7 +142,7 @@ func (t *TeamDB) Add(ctx context.Context, team core.Team) error {
 			}
 		}
 
-		_, err = tx.NamedExecContext(
+		_, err = t.db.q(ctx).NamedExecContext(
 			ctx,
 			`INSERT INTO users(id, name, is_active, team_name) 
 		 	 VALUES (:id, :name, :is_active, :team_name)
@@ -100,16 +158,15 @@ func (t *TeamDB) Add(ctx context.Context, team core.Team) error {
 		}
 	}
 
-	if err = tx.Commit(); err != nil {
-		return err
-	}
-
 	return nil
 }
 
 type Team struct {
-	Name    string `db:"name"`
-	Members []TeamMember
+	Name              string `db:"name"`
+	ReviewerStrategy  string `db:"reviewer_strategy"`
+	ReviewersRequired int    `db:"reviewers_required"`
+	ApprovalsRequired int    `db:"approvals_required"`
+	Members           []TeamMember
 }
 
 type TeamMember struct {
@@ -121,10 +178,11 @@ type TeamMember struct {
 func (t *TeamDB) Get(ctx context.Context, name string) (core.Team, error) {
 	var team Team
 
-	err := t.db.conn.GetContext(
+	err := t.db.q(ctx).GetContext(
 		ctx,
 		&team,
-		`SELECT name FROM teams WHERE name = $1`,
+		`SELECT name, reviewer_strategy, reviewers_required, approvals_required
+		 FROM teams WHERE name = $1`,
 		name,
 	)
 	if err != nil {
@@ -135,7 +193,7 @@ func (t *TeamDB) Get(ctx context.Context, name string) (core.Team, error) {
 	}
 
 	var members []TeamMember
-	err = t.db.conn.SelectContext(
+	err = t.db.q(ctx).SelectContext(
 		ctx,
 		&members,
 		`SELECT id, name, is_active
@@ -156,257 +214,1706 @@ func (t *TeamDB) Get(ctx context.Context, name string) (core.Team, error) {
 	}
 
 	return core.Team{
-		Name:    team.Name,
-		Members: coreMembers,
+		Name:              team.Name,
+		ReviewerStrategy:  team.ReviewerStrategy,
+		ReviewersRequired: team.ReviewersRequired,
+		ApprovalsRequired: team.ApprovalsRequired,
+		Members:           coreMembers,
 	}, nil
 }
 
-type UserDB struct {
-	db *DB
+func (t *TeamDB) UpdateSettings(ctx context.Context, name string, upd core.TeamUpdate) (core.TeamSettings, error) {
+	var team Team
+	err := t.db.q(ctx).GetContext(
+		ctx,
+		&team,
+		`UPDATE teams
+		 SET reviewer_strategy = COALESCE($1, reviewer_strategy),
+		     reviewers_required = COALESCE($2, reviewers_required),
+		     approvals_required = COALESCE($3, approvals_required)
+		 WHERE name = $4
+		 RETURNING name, reviewer_strategy, reviewers_required, approvals_required`,
+		upd.ReviewerStrategy, upd.ReviewersRequired, upd.ApprovalsRequired, name,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.TeamSettings{}, core.ErrNotFound
+		}
+		return core.TeamSettings{}, err
+	}
+	return core.TeamSettings{
+		Name:              team.Name,
+		ReviewerStrategy:  team.ReviewerStrategy,
+		ReviewersRequired: team.ReviewersRequired,
+		ApprovalsRequired: team.ApprovalsRequired,
+	}, nil
 }
 
-func NewUserDB(db *DB) *UserDB {
-	return &UserDB{db}
+func (t *TeamDB) DeactivateMembers(ctx context.Context, name string, userIDs []string) error {
+	_, err := t.db.q(ctx).ExecContext(
+		ctx,
+		`UPDATE users SET is_active = FALSE
+		 WHERE team_name = $1 AND id = ANY($2)`,
+		name, userIDs,
+	)
+	return err
 }
 
-type User struct {
-	ID       string `db:"id"`
-	Name     string `db:"name"`
-	TeamName string `db:"team_name"`
-	IsActive bool   `db:"is_active"`
+func (t *TeamDB) AddMember(ctx context.Context, name string, member core.TeamMember) error {
+	var ids []string
+	err := t.db.q(ctx).SelectContext(
+		ctx,
+		&ids,
+		`INSERT INTO users(id, name, is_active, team_name)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (id) DO UPDATE
+		 SET
+			 name = EXCLUDED.name,
+			 is_active = EXCLUDED.is_active,
+			 team_name = EXCLUDED.team_name
+		 WHERE users.team_name IS NULL OR users.team_name = EXCLUDED.team_name
+		 RETURNING id`,
+		member.ID, member.Name, member.IsActive, name,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == ForeignKeyViolation {
+			return core.ErrNotFound
+		}
+		return err
+	}
+	if len(ids) == 0 {
+		return core.ErrMemberOfOtherTeam
+	}
+	return nil
 }
 
-func (u *UserDB) UpdateIsActive(ctx context.Context, id string, isActive bool) (core.User, error) {
-	var user User
-	err := u.db.conn.GetContext(
+func (t *TeamDB) RemoveMember(ctx context.Context, name, userID string) error {
+	res, err := t.db.q(ctx).ExecContext(
 		ctx,
-		&user,
-		`UPDATE users SET is_active = $1 WHERE id = $2
-         RETURNING id, name, is_active, team_name`,
-		isActive, id,
+		`UPDATE users SET team_name = NULL WHERE id = $1 AND team_name = $2`,
+		userID, name,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return core.ErrNotMember
+	}
+	return nil
+}
+
+func (t *TeamDB) Rename(ctx context.Context, name, newName string) (core.TeamSettings, error) {
+	var team Team
+	err := t.db.q(ctx).GetContext(
+		ctx,
+		&team,
+		`UPDATE teams SET name = $2 WHERE name = $1
+		 RETURNING name, reviewer_strategy, reviewers_required, approvals_required`,
+		name, newName,
 	)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == UniqueViolation {
+			return core.TeamSettings{}, core.ErrAlreadyExists
+		}
 		if errors.Is(err, sql.ErrNoRows) {
-			return core.User{}, core.ErrNotFound
+			return core.TeamSettings{}, core.ErrNotFound
 		}
-		return core.User{}, err
+		return core.TeamSettings{}, err
 	}
-	return core.User{
-		ID:       user.ID,
-		Name:     user.Name,
-		TeamName: user.TeamName,
-		IsActive: user.IsActive,
+	return core.TeamSettings{
+		Name:              team.Name,
+		ReviewerStrategy:  team.ReviewerStrategy,
+		ReviewersRequired: team.ReviewersRequired,
+		ApprovalsRequired: team.ApprovalsRequired,
 	}, nil
 }
 
-type PRDB struct {
-	db *DB
+func (t *TeamDB) Delete(ctx context.Context, name string) error {
+	res, err := t.db.q(ctx).ExecContext(ctx, `DELETE FROM teams WHERE name = $1`, name)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return core.ErrNotFound
+	}
+	return nil
 }
 
-func NewPRDB(db *DB) *PRDB {
-	return &PRDB{db}
-}
-func (pr *PRDB) Get(ctx context.Context, id string) (core.PullRequest, error) {
-    var pullReq PullRequest
-    err := pr.db.conn.GetContext(
-        ctx,
-        &pullReq,
-        `SELECT id, name, author_id, status, reviewers, merged_at FROM prs WHERE id = $1`,
-        id,
-    )
-    if err != nil {
-        if errors.Is(err, sql.ErrNoRows) {
-            return core.PullRequest{}, core.ErrNotFound
-        }
-        return core.PullRequest{}, err
-    }
-    return core.PullRequest{
-        PullRequestShort: core.PullRequestShort{
-            ID:       pullReq.ID,
-            Name:     pullReq.Name,
-            AuthorID: pullReq.AuthorID,
-            Status:   pullReq.Status,
-        },
-        Reviewers: pullReq.Reviewers,
-        MergedAt:  pullReq.MergedAt,
-    }, nil
-}
-func (pr *PRDB) GetActiveTeamMemberIDsByUserID(ctx context.Context, authorID string) ([]string, error) {
-	var teamName string
-	err := pr.db.conn.GetContext(
-		ctx,
-		&teamName,
-		`SELECT team_name FROM users
-		 WHERE id = $1`,
-		authorID,
+func (t *TeamDB) SetReviewRule(ctx context.Context, rule core.ReviewRule) error {
+	_, err := t.db.q(ctx).ExecContext(
+		ctx,
+		`INSERT INTO team_review_rules (team_name, required_team, reviewers)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (team_name, required_team) DO UPDATE SET reviewers = EXCLUDED.reviewers`,
+		rule.TeamName, rule.RequiredTeam, rule.Reviewers,
 	)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, core.ErrNotFound
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == ForeignKeyViolation {
+			return core.ErrNotFound
 		}
-		return nil, err
+		return err
 	}
+	return nil
+}
 
-	var ids []string
-	err = pr.db.conn.SelectContext(
+func (t *TeamDB) DeleteReviewRule(ctx context.Context, name, requiredTeam string) error {
+	res, err := t.db.q(ctx).ExecContext(
 		ctx,
-		&ids,
-		`SELECT id FROM users
-		 WHERE team_name = $1
-		 AND is_active = TRUE`,
-		teamName,
+		`DELETE FROM team_review_rules WHERE team_name = $1 AND required_team = $2`,
+		name, requiredTeam,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return core.ErrNotFound
+	}
+	return nil
+}
+
+func (t *TeamDB) GetReviewRules(ctx context.Context, name string) ([]core.ReviewRule, error) {
+	var exists bool
+	err := t.db.q(ctx).GetContext(
+		ctx,
+		&exists,
+		`SELECT EXISTS (SELECT 1 FROM teams WHERE name = $1)`,
+		name,
 	)
 	if err != nil {
 		return nil, err
 	}
+	if !exists {
+		return nil, core.ErrNotFound
+	}
+	return t.db.getReviewRules(ctx, name)
+}
 
-	return ids, nil
+type ReviewRule struct {
+	TeamName     string `db:"team_name"`
+	RequiredTeam string `db:"required_team"`
+	Reviewers    int    `db:"reviewers"`
 }
-func (pr *PRDB) Add(ctx context.Context, prID, name, authorID string, reviewersID []string) error {
-	if reviewersID == nil {
-		reviewersID = []string{}
-	}
-	_, err := pr.db.conn.ExecContext(
+
+func (d *DB) getReviewRules(ctx context.Context, name string) ([]core.ReviewRule, error) {
+	var rules []ReviewRule
+	err := d.q(ctx).SelectContext(
 		ctx,
-		`INSERT INTO prs (id, name, author_id, status, reviewers)
-		 VALUES ($1, $2, $3, 'OPEN', $4)`,
-		prID, name, authorID, reviewersID,
+		&rules,
+		`SELECT team_name, required_team, reviewers FROM team_review_rules
+		 WHERE team_name = $1
+		 ORDER BY required_team`,
+		name,
 	)
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Code == UniqueViolation {
-				return core.ErrAlreadyExists
-			}
-			if pgErr.Code == ForeignKeyViolation {
-				return core.ErrNotFound
-			}
+		return nil, err
+	}
+
+	result := make([]core.ReviewRule, len(rules))
+	for i, r := range rules {
+		result[i] = core.ReviewRule{
+			TeamName:     r.TeamName,
+			RequiredTeam: r.RequiredTeam,
+			Reviewers:    r.Reviewers,
 		}
-		return err
 	}
-	return nil
+	return result, nil
 }
 
-type PullRequest struct {
-	ID        string         `db:"id"`
-	Name      string         `db:"name"`
-	AuthorID  string         `db:"author_id"`
-	Status    string         `db:"status"`
-	Reviewers pq.StringArray `db:"reviewers"`
-	MergedAt  *time.Time     `db:"merged_at"`
+type UserDB struct {
+	db *DB
 }
 
-func (pr *PRDB) UpdateMerged(ctx context.Context, id string) (core.PullRequest, error) {
-	var pullReq PullRequest
-	err := pr.db.conn.GetContext(
+func NewUserDB(db *DB) *UserDB {
+	return &UserDB{db}
+}
+
+type User struct {
+	ID       string `db:"id"`
+	Name     string `db:"name"`
+	TeamName string `db:"team_name"`
+	IsActive bool   `db:"is_active"`
+}
+
+func (u User) toCore() core.User {
+	return core.User{
+		ID:       u.ID,
+		Name:     u.Name,
+		TeamName: u.TeamName,
+		IsActive: u.IsActive,
+	}
+}
+
+func (u *UserDB) Get(ctx context.Context, id string) (core.User, error) {
+	var user User
+	err := u.db.q(ctx).GetContext(
 		ctx,
-		&pullReq,
-		`UPDATE prs SET status = 'MERGED', merged_at = COALESCE(merged_at, NOW())
-		 WHERE id = $1
-		 RETURNING id, name, author_id, status, reviewers, merged_at`,
+		&user,
+		`SELECT id, name, is_active, COALESCE(team_name, '') AS team_name
+		 FROM users WHERE id = $1`,
 		id,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return core.PullRequest{}, core.ErrNotFound
+			return core.User{}, core.ErrNotFound
 		}
-		return core.PullRequest{}, err
+		return core.User{}, err
 	}
-	return core.PullRequest{
-		PullRequestShort: core.PullRequestShort{
-			ID:       pullReq.ID,
-			Name:     pullReq.Name,
-			AuthorID: pullReq.AuthorID,
-			Status:   pullReq.Status,
-		},
-		Reviewers: pullReq.Reviewers,
-		MergedAt:  pullReq.MergedAt,
-	}, nil
+	return user.toCore(), nil
 }
-func (pr *PRDB) UpdateReviewer(ctx context.Context, prID, oldReviewerID, newReviewerID string) (core.PullRequest, error) {
-	var current struct {
-		Status    string         `db:"status"`
-		Reviewers pq.StringArray `db:"reviewers"`
+
+func (u *UserDB) List(ctx context.Context, filter core.UserFilter) ([]core.User, error) {
+	var users []User
+	err := u.db.q(ctx).SelectContext(
+		ctx,
+		&users,
+		`SELECT id, name, is_active, COALESCE(team_name, '') AS team_name
+		 FROM users
+		 WHERE ($1::text IS NULL OR team_name = $1)
+		 AND ($2::boolean IS NULL OR is_active = $2)
+		 ORDER BY id`,
+		filter.TeamName, filter.IsActive,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]core.User, len(users))
+	for i, user := range users {
+		result[i] = user.toCore()
 	}
+	return result, nil
+}
 
-	err := pr.db.conn.GetContext(
+func (u *UserDB) UpdateName(ctx context.Context, id, name string) (core.User, error) {
+	var user User
+	err := u.db.q(ctx).GetContext(
 		ctx,
-		&current,
-		`SELECT status, reviewers FROM prs WHERE id = $1`,
-		prID,
+		&user,
+		`UPDATE users SET name = $1 WHERE id = $2
+		 RETURNING id, name, is_active, COALESCE(team_name, '') AS team_name`,
+		name, id,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return core.PullRequest{}, core.ErrNotFound
+			return core.User{}, core.ErrNotFound
 		}
-		return core.PullRequest{}, err
+		return core.User{}, err
 	}
+	return user.toCore(), nil
+}
 
-	if current.Status == "MERGED" {
-		return core.PullRequest{}, core.ErrAlredyMerged
-	}
-	if !slices.Contains(current.Reviewers, oldReviewerID) {
-		return core.PullRequest{}, core.ErrNotAssigned
-	}
-	var updatedPR PullRequest
-	err = pr.db.conn.GetContext(
+// Delete keeps the user's merged and closed PRs without an author. The row
+// lock keeps a PR from being created for the user between the check and the
+// delete.
+func (u *UserDB) Delete(ctx context.Context, id string) error {
+	var hasOpenPRs bool
+	err := u.db.q(ctx).GetContext(
 		ctx,
-		&updatedPR,
-		`UPDATE prs 
-		 SET reviewers = array_replace(reviewers, $2, $3)
-		 WHERE id = $1
-		 RETURNING id, name, author_id, status, reviewers, merged_at`,
-		prID,
-		oldReviewerID,
-		newReviewerID,
+		&hasOpenPRs,
+		`SELECT EXISTS (
+		     SELECT 1 FROM prs WHERE author_id = u.id AND status IN ('OPEN', 'DRAFT')
+		 )
+		 FROM users u WHERE u.id = $1
+		 FOR UPDATE OF u`,
+		id,
 	)
 	if err != nil {
-		return core.PullRequest{}, err
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.ErrNotFound
+		}
+		return err
+	}
+	if hasOpenPRs {
+		return core.ErrHasPullRequests
 	}
 
-	return core.PullRequest{
-		PullRequestShort: core.PullRequestShort{
-			ID:       updatedPR.ID,
-			Name:     updatedPR.Name,
-			AuthorID: updatedPR.AuthorID,
-			Status:   updatedPR.Status,
-		},
-		Reviewers: updatedPR.Reviewers,
-		MergedAt:  updatedPR.MergedAt,
-	}, nil
-}
-
-type PullRequestShort struct {
-	ID       string `db:"id"`
-	Name     string `db:"name"`
-	AuthorID string `db:"author_id"`
-	Status   string `db:"status"`
+	_, err = u.db.q(ctx).ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id)
+	return err
 }
 
-func (pr *PRDB) GetByReviewer(ctx context.Context, reviewerID string) ([]core.PullRequestShort, error) {
-	var prs []PullRequest
-
-	err := pr.db.conn.SelectContext(
+func (u *UserDB) UpdateIsActive(ctx context.Context, id string, isActive bool) (core.User, error) {
+	var user User
+	err := u.db.q(ctx).GetContext(
 		ctx,
-		&prs,
-		`SELECT id, name, author_id, status
-         FROM prs
-         WHERE $1 = ANY(reviewers)`,
-		reviewerID,
+		&user,
+		`UPDATE users SET is_active = $1 WHERE id = $2
+         RETURNING id, name, is_active, COALESCE(team_name, '') AS team_name`,
+		isActive, id,
 	)
 	if err != nil {
-		return nil, err
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.User{}, core.ErrNotFound
+		}
+		return core.User{}, err
 	}
+	return user.toCore(), nil
+}
 
-	result := make([]core.PullRequestShort, len(prs))
-	for i, d := range prs {
+func (u *UserDB) UpdateTeam(ctx context.Context, id, teamName string) (core.User, error) {
+	var user User
+	err := u.db.q(ctx).GetContext(
+		ctx,
+		&user,
+		`UPDATE users SET team_name = $1 WHERE id = $2
+         RETURNING id, name, is_active, team_name`,
+		teamName, id,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, sql.ErrNoRows) ||
+			errors.As(err, &pgErr) && pgErr.Code == ForeignKeyViolation {
+			return core.User{}, core.ErrNotFound
+		}
+		return core.User{}, err
+	}
+	return user.toCore(), nil
+}
+
+type Absence struct {
+	ID       int64     `db:"id"`
+	UserID   string    `db:"user_id"`
+	StartsAt time.Time `db:"starts_at"`
+	EndsAt   time.Time `db:"ends_at"`
+	HandOff  bool      `db:"hand_off"`
+}
+
+func (a Absence) toCore() core.Absence {
+	return core.Absence{
+		ID:      a.ID,
+		UserID:  a.UserID,
+		From:    a.StartsAt,
+		To:      a.EndsAt,
+		HandOff: a.HandOff,
+	}
+}
+
+func (u *UserDB) AddAbsence(ctx context.Context, absence core.Absence) (core.Absence, error) {
+	var added Absence
+	err := u.db.q(ctx).GetContext(
+		ctx,
+		&added,
+		`INSERT INTO user_absences (user_id, starts_at, ends_at, hand_off)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, user_id, starts_at, ends_at, hand_off`,
+		absence.UserID, absence.From, absence.To, absence.HandOff,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == ForeignKeyViolation {
+			return core.Absence{}, core.ErrNotFound
+		}
+		return core.Absence{}, err
+	}
+	return added.toCore(), nil
+}
+func (u *UserDB) GetAbsences(ctx context.Context, userID string) ([]core.Absence, error) {
+	var absences []Absence
+	err := u.db.q(ctx).SelectContext(
+		ctx,
+		&absences,
+		`SELECT id, user_id, starts_at, ends_at, hand_off
+		 FROM user_absences
+		 WHERE user_id = $1
+		 ORDER BY starts_at`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]core.Absence, len(absences))
+	for i, a := range absences {
+		result[i] = a.toCore()
+	}
+	return result, nil
+}
+func (u *UserDB) DeleteAbsence(ctx context.Context, id int64) (core.Absence, error) {
+	var deleted Absence
+	err := u.db.q(ctx).GetContext(
+		ctx,
+		&deleted,
+		`DELETE FROM user_absences WHERE id = $1
+		 RETURNING id, user_id, starts_at, ends_at, hand_off`,
+		id,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.Absence{}, core.ErrNotFound
+		}
+		return core.Absence{}, err
+	}
+	return deleted.toCore(), nil
+}
+func (u *UserDB) TakeStartedHandoffs(ctx context.Context) ([]core.Absence, error) {
+	var absences []Absence
+	err := u.db.q(ctx).SelectContext(
+		ctx,
+		&absences,
+		`UPDATE user_absences SET handed_off_at = NOW()
+		 WHERE id IN (
+		     SELECT id FROM user_absences
+		     WHERE hand_off AND handed_off_at IS NULL
+		     AND starts_at <= NOW() AND ends_at > NOW()
+		     FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING id, user_id, starts_at, ends_at, hand_off`,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]core.Absence, len(absences))
+	for i, a := range absences {
+		result[i] = a.toCore()
+	}
+	return result, nil
+}
+
+type PRDB struct {
+	db *DB
+}
+
+func NewPRDB(db *DB) *PRDB {
+	return &PRDB{db}
+}
+func (pr *PRDB) Get(ctx context.Context, id string) (core.PullRequest, error) {
+    var pullReq PullRequest
+    err := pr.db.q(ctx).GetContext(
+        ctx,
+        &pullReq,
+        `SELECT id, name, COALESCE(author_id, '') AS author_id, status, reviewers_count, approvals_required,
+                COALESCE(repository, '') AS repository,
+                array_to_string(changed_files, E'\n') AS changed_files,
+                created_at, merged_at, closed_at
+         FROM prs WHERE id = $1`,
+        id,
+    )
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return core.PullRequest{}, core.ErrNotFound
+        }
+        return core.PullRequest{}, err
+    }
+    return pr.toCore(ctx, pullReq)
+}
+func (pr *PRDB) GetForUpdate(ctx context.Context, id string) (core.PullRequest, error) {
+	var pullReq PullRequest
+	err := pr.db.q(ctx).GetContext(
+		ctx,
+		&pullReq,
+		`SELECT id, name, COALESCE(author_id, '') AS author_id, status, reviewers_count, approvals_required,
+		        COALESCE(repository, '') AS repository,
+		        array_to_string(changed_files, E'\n') AS changed_files,
+		        created_at, merged_at, closed_at
+		 FROM prs WHERE id = $1 FOR UPDATE`,
+		id,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.PullRequest{}, core.ErrNotFound
+		}
+		return core.PullRequest{}, err
+	}
+	return pr.toCore(ctx, pullReq)
+}
+func (pr *PRDB) GetTeamSettings(ctx context.Context, teamName string) (core.TeamSettings, error) {
+	var team Team
+	err := pr.db.q(ctx).GetContext(
+		ctx,
+		&team,
+		`SELECT name, reviewer_strategy, reviewers_required, approvals_required
+		 FROM teams WHERE name = $1`,
+		teamName,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.TeamSettings{}, core.ErrNotFound
+		}
+		return core.TeamSettings{}, err
+	}
+	return core.TeamSettings{
+		Name:              team.Name,
+		ReviewerStrategy:  team.ReviewerStrategy,
+		ReviewersRequired: team.ReviewersRequired,
+		ApprovalsRequired: team.ApprovalsRequired,
+	}, nil
+}
+func (pr *PRDB) GetTeamSettingsByUserID(ctx context.Context, userID string) (core.TeamSettings, error) {
+	var team Team
+	err := pr.db.q(ctx).GetContext(
+		ctx,
+		&team,
+		`SELECT t.name, t.reviewer_strategy, t.reviewers_required, t.approvals_required
+		 FROM users u JOIN teams t ON t.name = u.team_name
+		 WHERE u.id = $1`,
+		userID,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.TeamSettings{}, core.ErrNotFound
+		}
+		return core.TeamSettings{}, err
+	}
+	return core.TeamSettings{
+		Name:              team.Name,
+		ReviewerStrategy:  team.ReviewerStrategy,
+		ReviewersRequired: team.ReviewersRequired,
+		ApprovalsRequired: team.ApprovalsRequired,
+	}, nil
+}
+func (pr *PRDB) GetActiveTeamMemberIDs(ctx context.Context, teamName string) ([]string, error) {
+	var ids []string
+	err := pr.db.q(ctx).SelectContext(
+		ctx,
+		&ids,
+		`SELECT id FROM users
+		 WHERE team_name = $1
+		 AND is_active = TRUE
+		 AND NOT EXISTS (
+		     SELECT 1 FROM user_absences a
+		     WHERE a.user_id = users.id
+		     AND a.starts_at <= NOW() AND a.ends_at > NOW()
+		 )`,
+		teamName,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (pr *PRDB) GetActiveUserIDs(ctx context.Context, userIDs []string) ([]string, error) {
+	var ids []string
+	err := pr.db.q(ctx).SelectContext(
+		ctx,
+		&ids,
+		`SELECT id FROM users
+		 WHERE id = ANY($1::text[])
+		 AND is_active = TRUE
+		 AND NOT EXISTS (
+		     SELECT 1 FROM user_absences a
+		     WHERE a.user_id = users.id
+		     AND a.starts_at <= NOW() AND a.ends_at > NOW()
+		 )
+		 ORDER BY id`,
+		userIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (pr *PRDB) GetCodeowners(ctx context.Context, repository string) (string, error) {
+	return pr.db.getCodeowners(ctx, repository)
+}
+
+func (pr *PRDB) GetRepository(ctx context.Context, name string) (core.Repository, error) {
+	return pr.db.getRepository(ctx, name)
+}
+
+func (pr *PRDB) GetReviewRules(ctx context.Context, teamName string) ([]core.ReviewRule, error) {
+	return pr.db.getReviewRules(ctx, teamName)
+}
+
+type ReviewCount struct {
+	UserID string `db:"user_id"`
+	Count  int    `db:"count"`
+}
+
+func (pr *PRDB) GetOpenReviewCounts(ctx context.Context, userIDs []string) (map[string]int, error) {
+	if len(userIDs) == 0 {
+		return map[string]int{}, nil
+	}
+
+	var counts []ReviewCount
+	err := pr.db.q(ctx).SelectContext(
+		ctx,
+		&counts,
+		`SELECT u.id AS user_id, COUNT(p.id) AS count
+		 FROM unnest($1::text[]) AS u(id)
+		 LEFT JOIN pr_reviewers r ON r.user_id = u.id
+		 LEFT JOIN prs p ON p.id = r.pr_id AND p.status = 'OPEN'
+		 GROUP BY u.id`,
+		userIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]int, len(counts))
+	for _, c := range counts {
+		result[c.UserID] = c.Count
+	}
+
+	return result, nil
+}
+
+// GetRoundRobinLast locks the cursor row until the end of the transaction,
+// so concurrent picks from the same team or pool do not repeat each other.
+func (pr *PRDB) GetRoundRobinLast(ctx context.Context, teamName, repository string) (string, error) {
+	query := `SELECT COALESCE(round_robin_last, '') FROM teams WHERE name = $1 FOR UPDATE`
+	key := teamName
+	if repository != "" {
+		query = `SELECT COALESCE(round_robin_last, '') FROM repositories WHERE name = $1 FOR UPDATE`
+		key = repository
+	}
+
+	var last string
+	err := pr.db.q(ctx).GetContext(ctx, &last, query, key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", core.ErrNotFound
+		}
+		return "", err
+	}
+	return last, nil
+}
+
+func (pr *PRDB) SetRoundRobinLast(ctx context.Context, teamName, repository, userID string) error {
+	query := `UPDATE teams SET round_robin_last = $2 WHERE name = $1`
+	key := teamName
+	if repository != "" {
+		query = `UPDATE repositories SET round_robin_last = $2 WHERE name = $1`
+		key = repository
+	}
+
+	_, err := pr.db.q(ctx).ExecContext(ctx, query, key, userID)
+	return err
+}
+func (pr *PRDB) Add(ctx context.Context, pullReq core.PullRequest) error {
+	return pr.db.WithinTx(ctx, func(ctx context.Context) error {
+		return pr.add(ctx, pullReq)
+	})
+}
+func (pr *PRDB) add(ctx context.Context, pullReq core.PullRequest) error {
+	var reviewersCount *int
+	if pullReq.ReviewersCount > 0 {
+		reviewersCount = &pullReq.ReviewersCount
+	}
+	changedFiles := pullReq.ChangedFiles
+	if changedFiles == nil {
+		changedFiles = []string{}
+	}
+	_, err := pr.db.q(ctx).ExecContext(
+		ctx,
+		`INSERT INTO prs (id, name, author_id, status, reviewers_count, approvals_required, repository, changed_files)
+		 VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8::text[])`,
+		pullReq.ID, pullReq.Name, pullReq.AuthorID, pullReq.Status, reviewersCount,
+		pullReq.ApprovalsRequired, pullReq.Repository, changedFiles,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Code == UniqueViolation {
+				return core.ErrAlreadyExists
+			}
+			if pgErr.Code == ForeignKeyViolation {
+				return core.ErrNotFound
+			}
+		}
+		return err
+	}
+
+	return pr.AddReviewers(ctx, pullReq.ID, pullReq.Reviewers, pullReq.RuleTeams)
+}
+func (pr *PRDB) AddReviewers(ctx context.Context, prID string, reviewersID []string, ruleTeams map[string]string) error {
+	if len(reviewersID) == 0 {
+		return nil
+	}
+	rules := make([]string, len(reviewersID))
+	for i, id := range reviewersID {
+		rules[i] = ruleTeams[id]
+	}
+	_, err := pr.db.q(ctx).ExecContext(
+		ctx,
+		`INSERT INTO pr_reviewers (pr_id, user_id, rule_team, pr_created_at)
+		 SELECT $1, r.user_id, NULLIF(r.rule_team, ''), (SELECT created_at FROM prs WHERE id = $1)
+		 FROM unnest($2::text[], $3::text[]) AS r(user_id, rule_team)`,
+		prID, reviewersID, rules,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Code == UniqueViolation {
+				return core.ErrAlreadyExists
+			}
+			if pgErr.Code == ForeignKeyViolation {
+				return core.ErrNotFound
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+type PullRequest struct {
+	ID                string     `db:"id"`
+	Name              string     `db:"name"`
+	AuthorID          string     `db:"author_id"`
+	Status            string     `db:"status"`
+	ReviewersCount    *int       `db:"reviewers_count"`
+	ApprovalsRequired int        `db:"approvals_required"`
+	Repository        string     `db:"repository"`
+	ChangedFiles      string     `db:"changed_files"`
+	CreatedAt         time.Time  `db:"created_at"`
+	MergedAt          *time.Time `db:"merged_at"`
+	ClosedAt          *time.Time `db:"closed_at"`
+}
+
+type Reviewer struct {
+	PRID       string    `db:"pr_id"`
+	UserID     string    `db:"user_id"`
+	State      string    `db:"state"`
+	RuleTeam   string    `db:"rule_team"`
+	AssignedAt time.Time `db:"assigned_at"`
+}
+
+func (pr *PRDB) toCore(ctx context.Context, pullReq PullRequest) (core.PullRequest, error) {
+	result, err := pr.toCoreAll(ctx, []PullRequest{pullReq})
+	if err != nil {
+		return core.PullRequest{}, err
+	}
+	return result[0], nil
+}
+
+// toCoreAll loads the reviewers of all the PRs with a single query.
+func (pr *PRDB) toCoreAll(ctx context.Context, prs []PullRequest) ([]core.PullRequest, error) {
+	ids := make([]string, len(prs))
+	for i, p := range prs {
+		ids[i] = p.ID
+	}
+
+	var reviewers []Reviewer
+	err := pr.db.q(ctx).SelectContext(
+		ctx,
+		&reviewers,
+		`SELECT pr_id, user_id, state, COALESCE(rule_team, '') AS rule_team, assigned_at FROM pr_reviewers
+		 WHERE pr_id = ANY($1)
+		 ORDER BY assigned_at, user_id`,
+		ids,
+	)
+	if err != nil {
+		return nil, err
+	}
+	byPR := make(map[string][]Reviewer, len(prs))
+	for _, r := range reviewers {
+		byPR[r.PRID] = append(byPR[r.PRID], r)
+	}
+
+	result := make([]core.PullRequest, len(prs))
+	for i, p := range prs {
+		result[i] = p.toCore(byPR[p.ID])
+	}
+	return result, nil
+}
+
+func (p PullRequest) toCore(reviewers []Reviewer) core.PullRequest {
+	reviewerIDs := make([]string, len(reviewers))
+	reviews := make([]core.Review, len(reviewers))
+	for i, r := range reviewers {
+		reviewerIDs[i] = r.UserID
+		reviews[i] = core.Review{
+			ReviewerID: r.UserID,
+			State:      r.State,
+			RuleTeam:   r.RuleTeam,
+		}
+	}
+
+	var reviewersCount int
+	if p.ReviewersCount != nil {
+		reviewersCount = *p.ReviewersCount
+	}
+	var changedFiles []string
+	if p.ChangedFiles != "" {
+		changedFiles = strings.Split(p.ChangedFiles, "\n")
+	}
+
+	return core.PullRequest{
+		PullRequestShort: core.PullRequestShort{
+			ID:        p.ID,
+			Name:      p.Name,
+			AuthorID:  p.AuthorID,
+			Status:    p.Status,
+			CreatedAt: p.CreatedAt,
+		},
+		Reviewers:         reviewerIDs,
+		Reviews:           reviews,
+		ReviewersCount:    reviewersCount,
+		ApprovalsRequired: p.ApprovalsRequired,
+		Repository:        p.Repository,
+		ChangedFiles:      changedFiles,
+		MergedAt:          p.MergedAt,
+		ClosedAt:          p.ClosedAt,
+	}
+}
+func (pr *PRDB) UpdateMerged(ctx context.Context, id string) (core.PullRequest, error) {
+	var pullReq PullRequest
+	err := pr.db.q(ctx).GetContext(
+		ctx,
+		&pullReq,
+		`UPDATE prs SET status = 'MERGED', merged_at = COALESCE(merged_at, NOW())
+		 WHERE id = $1
+		 RETURNING id, name, COALESCE(author_id, '') AS author_id, status, reviewers_count, approvals_required,
+		           COALESCE(repository, '') AS repository,
+		           array_to_string(changed_files, E'\n') AS changed_files,
+		           created_at, merged_at, closed_at`,
+		id,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.PullRequest{}, core.ErrNotFound
+		}
+		return core.PullRequest{}, err
+	}
+	return pr.toCore(ctx, pullReq)
+}
+func (pr *PRDB) UpdateStatus(ctx context.Context, id, status string) (core.PullRequest, error) {
+	var pullReq PullRequest
+	err := pr.db.q(ctx).GetContext(
+		ctx,
+		&pullReq,
+		`UPDATE prs
+		 SET status = $2,
+		     closed_at = CASE WHEN $2 = 'CLOSED' THEN NOW() END
+		 WHERE id = $1
+		 RETURNING id, name, COALESCE(author_id, '') AS author_id, status, reviewers_count, approvals_required,
+		           COALESCE(repository, '') AS repository,
+		           array_to_string(changed_files, E'\n') AS changed_files,
+		           created_at, merged_at, closed_at`,
+		id, status,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.PullRequest{}, core.ErrNotFound
+		}
+		return core.PullRequest{}, err
+	}
+	return pr.toCore(ctx, pullReq)
+}
+func (pr *PRDB) UpdateReviewer(ctx context.Context, prID, oldReviewerID, newReviewerID string) (core.PullRequest, error) {
+	res, err := pr.db.q(ctx).ExecContext(
+		ctx,
+		`UPDATE pr_reviewers
+		 SET user_id = $3, assigned_at = NOW(), state = 'PENDING'
+		 WHERE pr_id = $1 AND user_id = $2`,
+		prID,
+		oldReviewerID,
+		newReviewerID,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Code == UniqueViolation {
+				return core.PullRequest{}, core.ErrAlreadyExists
+			}
+			if pgErr.Code == ForeignKeyViolation {
+				return core.PullRequest{}, core.ErrNotFound
+			}
+		}
+		return core.PullRequest{}, err
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return core.PullRequest{}, err
+	}
+	if updated == 0 {
+		return core.PullRequest{}, core.ErrNotAssigned
+	}
+
+	return pr.Get(ctx, prID)
+}
+
+func (pr *PRDB) UpdateReviewState(ctx context.Context, prID, reviewerID, state string) error {
+	res, err := pr.db.q(ctx).ExecContext(
+		ctx,
+		`UPDATE pr_reviewers SET state = $3
+		 WHERE pr_id = $1 AND user_id = $2`,
+		prID, reviewerID, state,
+	)
+	if err != nil {
+		return err
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return core.ErrNotAssigned
+	}
+	return nil
+}
+
+type PullRequestShort struct {
+	ID        string    `db:"id"`
+	Name      string    `db:"name"`
+	AuthorID  string    `db:"author_id"`
+	Status    string    `db:"status"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (pr *PRDB) GetByReviewer(ctx context.Context, filter core.ReviewFilter) ([]core.PullRequestShort, error) {
+	var prs []PullRequestShort
+	// the keyset is on pr_reviewers, so the page is read straight from
+	// pr_reviewers_user_created_idx however long the reviewer's history is
+	keyset, args := "", []any{filter.ReviewerID, filter.Status}
+	if filter.After != nil {
+		keyset = "AND (r.pr_created_at, r.pr_id) < ($3, $4::text)"
+		args = append(args, filter.After.Time, filter.After.ID)
+	}
+	args = append(args, filter.Limit)
+
+	err := pr.db.q(ctx).SelectContext(
+		ctx,
+		&prs,
+		fmt.Sprintf(
+			`SELECT p.id, p.name, COALESCE(p.author_id, '') AS author_id, p.status, p.created_at
+			 FROM pr_reviewers r
+			 JOIN prs p ON p.id = r.pr_id
+			 WHERE r.user_id = $1
+			 AND ($2::text IS NULL OR p.status = $2)
+			 %s
+			 ORDER BY r.pr_created_at DESC, r.pr_id DESC
+			 LIMIT $%d`,
+			keyset, len(args),
+		),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]core.PullRequestShort, len(prs))
+	for i, d := range prs {
 		result[i] = core.PullRequestShort{
-			ID:       d.ID,
-			Name:     d.Name,
-			AuthorID: d.AuthorID,
-			Status:   d.Status,
+			ID:        d.ID,
+			Name:      d.Name,
+			AuthorID:  d.AuthorID,
+			Status:    d.Status,
+			CreatedAt: d.CreatedAt,
+		}
+	}
+
+	return result, nil
+}
+func (pr *PRDB) GetOpenIDsByReviewer(ctx context.Context, reviewerID string) ([]string, error) {
+	var ids []string
+	err := pr.db.q(ctx).SelectContext(
+		ctx,
+		&ids,
+		`SELECT p.id
+		 FROM pr_reviewers r
+		 JOIN prs p ON p.id = r.pr_id
+		 WHERE r.user_id = $1 AND p.status = 'OPEN'
+		 ORDER BY p.id`,
+		reviewerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+func (pr *PRDB) List(ctx context.Context, filter core.PRFilter) ([]core.PullRequest, error) {
+	order, cmp := "DESC", "<"
+	if filter.Ascending {
+		order, cmp = "ASC", ">"
+	}
+	var afterTime *time.Time
+	var afterID *string
+	if filter.After != nil {
+		afterTime, afterID = &filter.After.Time, &filter.After.ID
+	}
+
+	// changed files are not part of a listing, so they are not selected
+	var prs []PullRequest
+	err := pr.db.q(ctx).SelectContext(
+		ctx,
+		&prs,
+		fmt.Sprintf(
+			`SELECT p.id, p.name, COALESCE(p.author_id, '') AS author_id, p.status, p.reviewers_count, p.approvals_required,
+			        COALESCE(p.repository, '') AS repository,
+			        p.created_at, p.merged_at, p.closed_at
+			 FROM prs p
+			 LEFT JOIN users a ON a.id = p.author_id
+			 WHERE ($1::text IS NULL OR p.author_id = $1)
+			 AND ($2::text IS NULL OR EXISTS (
+			     SELECT 1 FROM pr_reviewers r WHERE r.pr_id = p.id AND r.user_id = $2
+			 ))
+			 AND ($3::text IS NULL OR a.team_name = $3)
+			 AND ($4::text IS NULL OR p.status = $4)
+			 AND ($5::timestamp IS NULL OR p.created_at >= $5)
+			 AND ($6::timestamp IS NULL OR p.created_at < $6)
+			 AND ($7::timestamp IS NULL OR p.merged_at >= $7)
+			 AND ($8::timestamp IS NULL OR p.merged_at < $8)
+			 AND ($9::timestamp IS NULL OR (p.created_at, p.id) %s ($9, $10::text))
+			 ORDER BY p.created_at %s, p.id %s
+			 LIMIT $11`,
+			cmp, order, order,
+		),
+		filter.AuthorID, filter.ReviewerID, filter.TeamName, filter.Status,
+		filter.CreatedFrom, filter.CreatedTo, filter.MergedFrom, filter.MergedTo,
+		afterTime, afterID, filter.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return pr.toCoreAll(ctx, prs)
+}
+
+type PREvent struct {
+	ID          int64     `db:"id"`
+	PRID        string    `db:"pr_id"`
+	Type        string    `db:"type"`
+	ActorID     string    `db:"actor_id"`
+	OldUserID   string    `db:"old_user_id"`
+	NewUserID   string    `db:"new_user_id"`
+	OldStatus   string    `db:"old_status"`
+	NewStatus   string    `db:"new_status"`
+	ReviewState string    `db:"review_state"`
+	Reason      string    `db:"reason"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+func (pr *PRDB) AddEvents(ctx context.Context, events []core.PREvent) error {
+	for _, e := range events {
+		_, err := pr.db.q(ctx).ExecContext(
+			ctx,
+			`WITH e AS (
+			     INSERT INTO pr_events
+			         (pr_id, type, actor_id, old_user_id, new_user_id,
+			          old_status, new_status, review_state, reason)
+			     VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''),
+			             NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''))
+			     RETURNING *
+			 )
+			 INSERT INTO outbox (topic, payload)
+			 SELECT e.type, jsonb_build_object(
+			     'id', e.id, 'pr_id', e.pr_id, 'type', e.type,
+			     'actor_id', e.actor_id, 'old_user_id', e.old_user_id, 'new_user_id', e.new_user_id,
+			     'old_status', e.old_status, 'new_status', e.new_status,
+			     'review_state', e.review_state, 'reason', e.reason,
+			     'created_at', to_char(e.created_at, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"'))
+			 FROM e`,
+			e.PRID, e.Type, e.ActorID, e.OldUserID, e.NewUserID,
+			e.OldStatus, e.NewStatus, e.ReviewState, e.Reason,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (pr *PRDB) GetEvents(ctx context.Context, prID string) ([]core.PREvent, error) {
+	var events []PREvent
+	err := pr.db.q(ctx).SelectContext(
+		ctx,
+		&events,
+		`SELECT id, pr_id, type,
+		        COALESCE(actor_id, '') AS actor_id,
+		        COALESCE(old_user_id, '') AS old_user_id,
+		        COALESCE(new_user_id, '') AS new_user_id,
+		        COALESCE(old_status, '') AS old_status,
+		        COALESCE(new_status, '') AS new_status,
+		        COALESCE(review_state, '') AS review_state,
+		        COALESCE(reason, '') AS reason,
+		        created_at
+		 FROM pr_events
+		 WHERE pr_id = $1
+		 ORDER BY id`,
+		prID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]core.PREvent, len(events))
+	for i, e := range events {
+		result[i] = core.PREvent{
+			ID:          e.ID,
+			PRID:        e.PRID,
+			Type:        e.Type,
+			ActorID:     e.ActorID,
+			OldUserID:   e.OldUserID,
+			NewUserID:   e.NewUserID,
+			OldStatus:   e.OldStatus,
+			NewStatus:   e.NewStatus,
+			ReviewState: e.ReviewState,
+			Reason:      e.Reason,
+			CreatedAt:   e.CreatedAt,
+		}
+	}
+	return result, nil
+}
+
+type StatsDB struct {
+	db *DB
+}
+
+func NewStatsDB(db *DB) *StatsDB {
+	return &StatsDB{db}
+}
+
+type ReviewerStats struct {
+	UserID          string   `db:"user_id"`
+	Name            string   `db:"name"`
+	TeamName        string   `db:"team_name"`
+	Assigned        int      `db:"assigned"`
+	Open            int      `db:"open"`
+	Merged          int      `db:"merged"`
+	ReassignedAway  int      `db:"reassigned_away"`
+	AvgMergeSeconds *float64 `db:"avg_merge_seconds"`
+}
+
+func (s *StatsDB) GetReviewerStats(ctx context.Context, filter core.StatsFilter) ([]core.ReviewerStats, error) {
+	var rows []ReviewerStats
+	err := s.db.q(ctx).SelectContext(
+		ctx,
+		&rows,
+		`WITH reviewing AS (
+		     SELECT r.user_id,
+		            COUNT(*) FILTER (WHERE p.status = 'OPEN') AS open,
+		            COUNT(*) FILTER (WHERE p.status = 'MERGED') AS merged,
+		            AVG(EXTRACT(EPOCH FROM p.merged_at - r.assigned_at))
+		                FILTER (WHERE p.status = 'MERGED') AS avg_merge_seconds
+		     FROM pr_reviewers r
+		     JOIN prs p ON p.id = r.pr_id
+		     WHERE ($2::timestamp IS NULL OR r.assigned_at >= $2)
+		     AND ($3::timestamp IS NULL OR r.assigned_at < $3)
+		     GROUP BY r.user_id
+		 ), assigned AS (
+		     -- assignments and reassignments away both come from the events of
+		     -- the window; the backfilled history has an ASSIGNED event for
+		     -- reviewers that came in by reassignment, hence DISTINCT
+		     SELECT new_user_id AS user_id, COUNT(DISTINCT pr_id) AS assigned
+		     FROM pr_events
+		     WHERE type IN ('ASSIGNED', 'REASSIGNED')
+		     AND ($2::timestamp IS NULL OR created_at >= $2)
+		     AND ($3::timestamp IS NULL OR created_at < $3)
+		     GROUP BY new_user_id
+		 ), reassigned AS (
+		     SELECT old_user_id AS user_id, COUNT(*) AS reassigned_away
+		     FROM pr_events
+		     WHERE type = 'REASSIGNED'
+		     AND ($2::timestamp IS NULL OR created_at >= $2)
+		     AND ($3::timestamp IS NULL OR created_at < $3)
+		     GROUP BY old_user_id
+		 )
+		 SELECT u.id AS user_id, u.name, COALESCE(u.team_name, '') AS team_name,
+		        COALESCE(a.assigned, 0) AS assigned,
+		        COALESCE(c.open, 0) AS open,
+		        COALESCE(c.merged, 0) AS merged,
+		        COALESCE(ra.reassigned_away, 0) AS reassigned_away,
+		        c.avg_merge_seconds
+		 FROM users u
+		 LEFT JOIN reviewing c ON c.user_id = u.id
+		 LEFT JOIN assigned a ON a.user_id = u.id
+		 LEFT JOIN reassigned ra ON ra.user_id = u.id
+		 WHERE ($1::text IS NULL OR u.team_name = $1)
+		 ORDER BY u.id`,
+		filter.TeamName, filter.From, filter.To,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]core.ReviewerStats, len(rows))
+	for i, r := range rows {
+		result[i] = core.ReviewerStats{
+			UserID:         r.UserID,
+			Name:           r.Name,
+			TeamName:       r.TeamName,
+			Assigned:       r.Assigned,
+			Open:           r.Open,
+			Merged:         r.Merged,
+			ReassignedAway: r.ReassignedAway,
+		}
+		if r.AvgMergeSeconds != nil {
+			avg := time.Duration(*r.AvgMergeSeconds * float64(time.Second))
+			result[i].AvgTimeToMerge = &avg
+		}
+	}
+	return result, nil
+}
+
+type WebhookDB struct {
+	db *DB
+}
+
+func NewWebhookDB(db *DB) *WebhookDB {
+	return &WebhookDB{db}
+}
+
+type Webhook struct {
+	ID        int64     `db:"id"`
+	URL       string    `db:"url"`
+	Secret    string    `db:"secret"`
+	Events    string    `db:"events"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (h Webhook) toCore() core.Webhook {
+	return core.Webhook{
+		ID:        h.ID,
+		URL:       h.URL,
+		Secret:    h.Secret,
+		Events:    strings.Split(h.Events, ","),
+		CreatedAt: h.CreatedAt,
+	}
+}
+
+func (wh *WebhookDB) Add(ctx context.Context, hook core.Webhook) (core.Webhook, error) {
+	var added Webhook
+	err := wh.db.q(ctx).GetContext(
+		ctx,
+		&added,
+		`INSERT INTO webhooks (url, secret, events)
+		 VALUES ($1, $2, $3::text[])
+		 RETURNING id, url, secret, array_to_string(events, ',') AS events, created_at`,
+		hook.URL, hook.Secret, hook.Events,
+	)
+	if err != nil {
+		return core.Webhook{}, err
+	}
+	return added.toCore(), nil
+}
+
+func (wh *WebhookDB) List(ctx context.Context) ([]core.Webhook, error) {
+	var hooks []Webhook
+	err := wh.db.q(ctx).SelectContext(
+		ctx,
+		&hooks,
+		`SELECT id, url, secret, array_to_string(events, ',') AS events, created_at
+		 FROM webhooks ORDER BY id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]core.Webhook, len(hooks))
+	for i, h := range hooks {
+		result[i] = h.toCore()
+	}
+	return result, nil
+}
+
+func (wh *WebhookDB) Delete(ctx context.Context, id int64) error {
+	res, err := wh.db.q(ctx).ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return core.ErrNotFound
+	}
+	return nil
+}
+
+func (wh *WebhookDB) Enqueue(ctx context.Context, eventID, event string, payload []byte) error {
+	_, err := wh.db.q(ctx).ExecContext(
+		ctx,
+		`INSERT INTO webhook_deliveries (webhook_id, event_id, event, payload)
+		 SELECT id, $1, $2, $3::jsonb FROM webhooks WHERE $2 = ANY(events)
+		 ON CONFLICT (webhook_id, event_id) DO NOTHING`,
+		eventID, event, string(payload),
+	)
+	return err
+}
+
+type WebhookDelivery struct {
+	ID        int64  `db:"id"`
+	WebhookID int64  `db:"webhook_id"`
+	URL       string `db:"url"`
+	Secret    string `db:"secret"`
+	Event     string `db:"event"`
+	Payload   string `db:"payload"`
+	Attempts  int    `db:"attempts"`
+}
+
+func (wh *WebhookDB) TakeDue(ctx context.Context, limit int, lease time.Duration) ([]core.WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	err := wh.db.q(ctx).SelectContext(
+		ctx,
+		&deliveries,
+		`UPDATE webhook_deliveries d
+		 SET next_attempt_at = NOW() + make_interval(secs => $2)
+		 FROM webhooks w
+		 WHERE w.id = d.webhook_id
+		 AND d.id IN (
+		     SELECT id FROM webhook_deliveries
+		     WHERE delivered_at IS NULL AND failed_at IS NULL
+		     AND next_attempt_at <= NOW()
+		     ORDER BY next_attempt_at
+		     LIMIT $1
+		     FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING d.id, d.webhook_id, w.url, w.secret, d.event, d.payload::text AS payload, d.attempts`,
+		limit, lease.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]core.WebhookDelivery, len(deliveries))
+	for i, d := range deliveries {
+		result[i] = core.WebhookDelivery{
+			ID:        d.ID,
+			WebhookID: d.WebhookID,
+			URL:       d.URL,
+			Secret:    d.Secret,
+			Event:     d.Event,
+			Payload:   []byte(d.Payload),
+			Attempts:  d.Attempts,
+		}
+	}
+	return result, nil
+}
+
+func (wh *WebhookDB) MarkDelivered(ctx context.Context, id int64) error {
+	_, err := wh.db.q(ctx).ExecContext(
+		ctx,
+		`UPDATE webhook_deliveries
+		 SET delivered_at = NOW(), attempts = attempts + 1, last_error = NULL
+		 WHERE id = $1`,
+		id,
+	)
+	return err
+}
+
+func (wh *WebhookDB) MarkRetry(ctx context.Context, id int64, next time.Time, reason string) error {
+	_, err := wh.db.q(ctx).ExecContext(
+		ctx,
+		`UPDATE webhook_deliveries
+		 SET next_attempt_at = $2, attempts = attempts + 1, last_error = $3
+		 WHERE id = $1`,
+		id, next, reason,
+	)
+	return err
+}
+
+func (wh *WebhookDB) MarkFailed(ctx context.Context, id int64, reason string) error {
+	_, err := wh.db.q(ctx).ExecContext(
+		ctx,
+		`UPDATE webhook_deliveries
+		 SET failed_at = NOW(), attempts = attempts + 1, last_error = $2
+		 WHERE id = $1`,
+		id, reason,
+	)
+	return err
+}
+
+type OutboxDB struct {
+	db *DB
+}
+
+func NewOutboxDB(db *DB) *OutboxDB {
+	return &OutboxDB{db}
+}
+
+type OutboxMessage struct {
+	ID       int64  `db:"id"`
+	EventID  string `db:"event_id"`
+	Topic    string `db:"topic"`
+	Payload  string `db:"payload"`
+	Attempts int    `db:"attempts"`
+}
+
+func (o *OutboxDB) TakeDue(ctx context.Context, limit int, lease time.Duration) ([]core.OutboxMessage, error) {
+	var msgs []OutboxMessage
+	err := o.db.q(ctx).SelectContext(
+		ctx,
+		&msgs,
+		`UPDATE outbox
+		 SET next_attempt_at = NOW() + make_interval(secs => $2)
+		 WHERE id IN (
+		     SELECT id FROM outbox
+		     WHERE dispatched_at IS NULL
+		     AND next_attempt_at <= NOW()
+		     ORDER BY id
+		     LIMIT $1
+		     FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING id, event_id::text AS event_id, topic, payload::text AS payload, attempts`,
+		limit, lease.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(msgs, func(a, b OutboxMessage) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	result := make([]core.OutboxMessage, len(msgs))
+	for i, m := range msgs {
+		result[i] = core.OutboxMessage{
+			ID:       m.ID,
+			EventID:  m.EventID,
+			Topic:    m.Topic,
+			Payload:  []byte(m.Payload),
+			Attempts: m.Attempts,
+		}
+	}
+	return result, nil
+}
+
+func (o *OutboxDB) MarkDispatched(ctx context.Context, id int64) error {
+	_, err := o.db.q(ctx).ExecContext(
+		ctx,
+		`UPDATE outbox
+		 SET dispatched_at = NOW(), attempts = attempts + 1, last_error = NULL
+		 WHERE id = $1`,
+		id,
+	)
+	return err
+}
+
+func (o *OutboxDB) MarkRetry(ctx context.Context, id int64, next time.Time, reason string) error {
+	_, err := o.db.q(ctx).ExecContext(
+		ctx,
+		`UPDATE outbox
+		 SET next_attempt_at = $2, attempts = attempts + 1, last_error = $3
+		 WHERE id = $1`,
+		id, next, reason,
+	)
+	return err
+}
+
+type RepositoryDB struct {
+	db *DB
+}
+
+func NewRepositoryDB(db *DB) *RepositoryDB {
+	return &RepositoryDB{db}
+}
+
+func (r *RepositoryDB) SetCodeowners(ctx context.Context, repository, content string) error {
+	return r.db.WithinTx(ctx, func(ctx context.Context) error {
+		// registering CODEOWNERS registers the repository as well
+		_, err := r.db.q(ctx).ExecContext(
+			ctx,
+			`INSERT INTO repositories (name) VALUES ($1) ON CONFLICT DO NOTHING`,
+			repository,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = r.db.q(ctx).ExecContext(
+			ctx,
+			`INSERT INTO codeowners (repository, content)
+			 VALUES ($1, $2)
+			 ON CONFLICT (repository) DO UPDATE
+			 SET content = EXCLUDED.content, updated_at = NOW()`,
+			repository, content,
+		)
+		return err
+	})
+}
+
+func (r *RepositoryDB) GetCodeowners(ctx context.Context, repository string) (string, error) {
+	return r.db.getCodeowners(ctx, repository)
+}
+
+func (d *DB) getCodeowners(ctx context.Context, repository string) (string, error) {
+	var content string
+	err := d.q(ctx).GetContext(
+		ctx,
+		&content,
+		`SELECT content FROM codeowners WHERE repository = $1`,
+		repository,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", core.ErrNotFound
+		}
+		return "", err
+	}
+	return content, nil
+}
+
+type Repository struct {
+	Name              string    `db:"name"`
+	ReviewersRequired *int      `db:"reviewers_required"`
+	CreatedAt         time.Time `db:"created_at"`
+}
+
+func (d *DB) getRepository(ctx context.Context, name string) (core.Repository, error) {
+	var repo Repository
+	err := d.q(ctx).GetContext(
+		ctx,
+		&repo,
+		`SELECT name, reviewers_required, created_at FROM repositories WHERE name = $1`,
+		name,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return core.Repository{}, core.ErrNotFound
 		}
+		return core.Repository{}, err
 	}
 
+	result := core.Repository{
+		Name:      repo.Name,
+		CreatedAt: repo.CreatedAt,
+	}
+	if repo.ReviewersRequired != nil {
+		result.ReviewersRequired = *repo.ReviewersRequired
+	}
+	err = d.q(ctx).SelectContext(
+		ctx,
+		&result.OwningTeams,
+		`SELECT team_name FROM repository_teams WHERE repository = $1 ORDER BY team_name`,
+		name,
+	)
+	if err != nil {
+		return core.Repository{}, err
+	}
+	err = d.q(ctx).SelectContext(
+		ctx,
+		&result.ReviewerPool,
+		`SELECT user_id FROM repository_reviewers WHERE repository = $1 ORDER BY user_id`,
+		name,
+	)
+	if err != nil {
+		return core.Repository{}, err
+	}
 	return result, nil
 }
+
+func (r *RepositoryDB) Add(ctx context.Context, repo core.Repository) (core.Repository, error) {
+	err := r.db.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := r.db.q(ctx).ExecContext(
+			ctx,
+			`INSERT INTO repositories (name, reviewers_required) VALUES ($1, NULLIF($2, 0))`,
+			repo.Name, repo.ReviewersRequired,
+		)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == UniqueViolation {
+				return core.ErrAlreadyExists
+			}
+			return err
+		}
+		if err = r.setOwningTeams(ctx, repo.Name, repo.OwningTeams); err != nil {
+			return err
+		}
+		return r.setReviewerPool(ctx, repo.Name, repo.ReviewerPool)
+	})
+	if err != nil {
+		return core.Repository{}, err
+	}
+	return r.db.getRepository(ctx, repo.Name)
+}
+
+func (r *RepositoryDB) Get(ctx context.Context, name string) (core.Repository, error) {
+	return r.db.getRepository(ctx, name)
+}
+
+func (r *RepositoryDB) Update(ctx context.Context, name string, upd core.RepositoryUpdate) (core.Repository, error) {
+	var repo core.Repository
+	err := r.db.WithinTx(ctx, func(ctx context.Context) error {
+		res, err := r.db.q(ctx).ExecContext(
+			ctx,
+			`UPDATE repositories
+			 SET reviewers_required = CASE WHEN $2::int IS NULL THEN reviewers_required
+			                               ELSE NULLIF($2, 0) END
+			 WHERE name = $1`,
+			name, upd.ReviewersRequired,
+		)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return core.ErrNotFound
+		}
+
+		if upd.OwningTeams != nil {
+			if err = r.setOwningTeams(ctx, name, *upd.OwningTeams); err != nil {
+				return err
+			}
+		}
+		if upd.ReviewerPool != nil {
+			if err = r.setReviewerPool(ctx, name, *upd.ReviewerPool); err != nil {
+				return err
+			}
+		}
+		repo, err = r.db.getRepository(ctx, name)
+		return err
+	})
+	if err != nil {
+		return core.Repository{}, err
+	}
+	return repo, nil
+}
+
+func (r *RepositoryDB) setOwningTeams(ctx context.Context, name string, teams []string) error {
+	_, err := r.db.q(ctx).ExecContext(ctx, `DELETE FROM repository_teams WHERE repository = $1`, name)
+	if err != nil {
+		return err
+	}
+	if len(teams) == 0 {
+		return nil
+	}
+	_, err = r.db.q(ctx).ExecContext(
+		ctx,
+		`INSERT INTO repository_teams (repository, team_name)
+		 SELECT DISTINCT $1, unnest($2::text[])`,
+		name, teams,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == ForeignKeyViolation {
+			return core.ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *RepositoryDB) setReviewerPool(ctx context.Context, name string, userIDs []string) error {
+	_, err := r.db.q(ctx).ExecContext(ctx, `DELETE FROM repository_reviewers WHERE repository = $1`, name)
+	if err != nil {
+		return err
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+	_, err = r.db.q(ctx).ExecContext(
+		ctx,
+		`INSERT INTO repository_reviewers (repository, user_id)
+		 SELECT DISTINCT $1, unnest($2::text[])`,
+		name, userIDs,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == ForeignKeyViolation {
+			return core.ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package forge
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"pull_req/pull_req/core"
+)
+
+const (
+	ActionOpened   = "opened"
+	ActionMerged   = "merged"
+	ActionClosed   = "closed"
+	ActionReopened = "reopened"
+	ActionReady    = "ready"
+
+	maxPayloadSize = 25 << 20
+)
+
+// Event is a pull/merge request change translated from a forge payload.
+type Event struct {
+	Action      string
+	PRID        string
+	Title       string
+	AuthorLogin string
+	Draft       bool
+}
+
+// PRID namespaces a forge's own pull request reference with the forge name.
+// GitHub and Gitea both write theirs as "owner/repo#number", without the
+// prefix the two would collide and the GitHub client would pick up Gitea PRs.
+func PRID(forge, ref string) string {
+	return forge + ":" + ref
+}
+
+// Parser is implemented by every forge adapter. Parse returns ok=false for
+// deliveries that are valid but carry nothing we act on.
+type Parser interface {
+	Name() string
+	Verify(r *http.Request, body []byte) bool
+	Parse(r *http.Request, body []byte) (event Event, ok bool, err error)
+}
+
+func NewWebhookHandler(log *slog.Logger, pr core.PRPort, parser Parser, logins map[string]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxPayloadSize))
+		if err != nil {
+			log.Error("read body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+		if !parser.Verify(r, body) {
+			log.Error("forge webhook verification failed", "forge", parser.Name())
+			http.Error(w, "Invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		event, ok, err := parser.Parse(r, body)
+		if err != nil {
+			log.Error("decode body problem", "forge", parser.Name(), "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+		if !ok {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		switch event.Action {
+		case ActionOpened:
+			authorID, known := logins[event.AuthorLogin]
+			if !known {
+				log.Error("unknown forge login", "forge", parser.Name(), "login", event.AuthorLogin)
+				http.Error(w, "Unknown login", http.StatusUnprocessableEntity)
+				return
+			}
+			_, err = pr.Create(r.Context(), core.NewPullRequest{
+				ID:       event.PRID,
+				Name:     event.Title,
+				AuthorID: authorID,
+				Draft:    event.Draft,
+			})
+			if errors.Is(err, core.ErrAlreadyExists) {
+				// forges redeliver on timeouts, the PR is already here
+				err = nil
+			}
+		case ActionMerged:
+			// the forge has merged it already, our approval rules can not stop that
+			_, err = pr.RecordMerge(r.Context(), event.PRID)
+		case ActionClosed:
+			_, err = pr.Close(r.Context(), event.PRID)
+		case ActionReopened:
+			_, err = pr.Reopen(r.Context(), event.PRID)
+		case ActionReady:
+			_, err = pr.MarkReady(r.Context(), event.PRID)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("pr/author not found", "pr", event.PRID, "error", err)
+				http.Error(w, "PR/author not found", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, core.ErrNotApproved) || errors.Is(err, core.ErrChangesRequested) || errors.Is(err, core.ErrPRClosed) ||
+				errors.Is(err, core.ErrPRDraft) || errors.Is(err, core.ErrAlredyMerged) || errors.Is(err, core.ErrRuleUnsatisfied) {
+				log.Error("pr state conflict", "pr", event.PRID, "action", event.Action, "error", err)
+				http.Error(w, "PR state conflict", http.StatusConflict)
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,129 @@
+package forge
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"pull_req/pull_req/core"
+	"strings"
+	"testing"
+)
+
+type fakeParser struct {
+	valid bool
+	event Event
+	ok    bool
+}
+
+func (p fakeParser) Name() string {
+	return "fake"
+}
+
+func (p fakeParser) Verify(*http.Request, []byte) bool {
+	return p.valid
+}
+
+func (p fakeParser) Parse(*http.Request, []byte) (Event, bool, error) {
+	return p.event, p.ok, nil
+}
+
+type fakePRs struct {
+	core.PRPort
+	calls []string
+	prs   map[string]core.NewPullRequest
+	err   error
+}
+
+func (f *fakePRs) Create(_ context.Context, newPR core.NewPullRequest) (core.PullRequest, error) {
+	f.calls = append(f.calls, "create "+newPR.ID)
+	if _, ok := f.prs[newPR.ID]; ok {
+		return core.PullRequest{}, core.ErrAlreadyExists
+	}
+	f.prs[newPR.ID] = newPR
+	return core.PullRequest{}, nil
+}
+
+func (f *fakePRs) RecordMerge(_ context.Context, id string) (core.PullRequest, error) {
+	f.calls = append(f.calls, "merge "+id)
+	return core.PullRequest{}, f.err
+}
+
+func (f *fakePRs) Close(_ context.Context, id string) (core.PullRequest, error) {
+	f.calls = append(f.calls, "close "+id)
+	return core.PullRequest{}, f.err
+}
+
+func (f *fakePRs) Reopen(_ context.Context, id string) (core.PullRequest, error) {
+	f.calls = append(f.calls, "reopen "+id)
+	return core.PullRequest{}, f.err
+}
+
+func (f *fakePRs) MarkReady(_ context.Context, id string) (core.PullRequest, error) {
+	f.calls = append(f.calls, "ready "+id)
+	return core.PullRequest{}, f.err
+}
+
+func serve(prs *fakePRs, parser Parser) int {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := NewWebhookHandler(log, prs, parser, map[string]string{"octocat": "u1"})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader("{}")))
+	return rec.Code
+}
+
+func TestHandlerActions(t *testing.T) {
+	prs := &fakePRs{prs: map[string]core.NewPullRequest{}}
+	opened := Event{Action: ActionOpened, PRID: "repo#1", Title: "Stats", AuthorLogin: "octocat", Draft: true}
+
+	steps := []struct {
+		event  Event
+		ok     bool
+		status int
+	}{
+		{opened, true, http.StatusOK},
+		{opened, true, http.StatusOK},
+		{Event{}, false, http.StatusAccepted},
+		{Event{Action: ActionReady, PRID: "repo#1"}, true, http.StatusOK},
+		{Event{Action: ActionClosed, PRID: "repo#1"}, true, http.StatusOK},
+		{Event{Action: ActionReopened, PRID: "repo#1"}, true, http.StatusOK},
+		{Event{Action: ActionMerged, PRID: "repo#1"}, true, http.StatusOK},
+	}
+	for i, s := range steps {
+		if got := serve(prs, fakeParser{valid: true, event: s.event, ok: s.ok}); got != s.status {
+			t.Fatalf("step %d: unexpected status %d", i, got)
+		}
+	}
+
+	want := []string{"create repo#1", "create repo#1", "ready repo#1", "close repo#1", "reopen repo#1", "merge repo#1"}
+	if strings.Join(prs.calls, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected calls %v", prs.calls)
+	}
+	created := prs.prs["repo#1"]
+	if created.AuthorID != "u1" || created.Name != "Stats" || !created.Draft {
+		t.Fatalf("unexpected pr %+v", created)
+	}
+}
+
+func TestHandlerRejects(t *testing.T) {
+	prs := &fakePRs{prs: map[string]core.NewPullRequest{}}
+	if got := serve(prs, fakeParser{valid: false}); got != http.StatusUnauthorized {
+		t.Fatalf("unexpected status for bad signature %d", got)
+	}
+
+	unknown := Event{Action: ActionOpened, PRID: "repo#2", AuthorLogin: "ghost"}
+	if got := serve(prs, fakeParser{valid: true, event: unknown, ok: true}); got != http.StatusUnprocessableEntity {
+		t.Fatalf("unexpected status for unknown login %d", got)
+	}
+	if len(prs.calls) != 0 {
+		t.Fatalf("unexpected calls %v", prs.calls)
+	}
+
+	prs.err = core.ErrPRClosed
+	reopened := Event{Action: ActionReopened, PRID: "repo#2"}
+	if got := serve(prs, fakeParser{valid: true, event: reopened, ok: true}); got != http.StatusConflict {
+		t.Fatalf("unexpected status for a conflicting reopen %d", got)
+	}
+}
@@ -0,0 +1,137 @@
+package gitea
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"pull_req/pull_req/adapters/forge"
+	"strings"
+)
+
+const (
+	name = "gitea"
+
+	HeaderEvent     = "X-Gitea-Event"
+	HeaderSignature = "X-Gitea-Signature"
+)
+
+type User struct {
+	Login string `json:"login"`
+}
+
+type PullRequest struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Draft  bool   `json:"draft"`
+	Merged bool   `json:"merged"`
+	User   User   `json:"user"`
+}
+
+type Repository struct {
+	FullName string `json:"full_name"`
+}
+
+type Change struct {
+	From string `json:"from"`
+}
+
+type Changes struct {
+	Title *Change `json:"title"`
+}
+
+type PullRequestEvent struct {
+	Action      string      `json:"action"`
+	Number      int         `json:"number"`
+	Changes     Changes     `json:"changes"`
+	PullRequest PullRequest `json:"pull_request"`
+	Repository  Repository  `json:"repository"`
+}
+
+// PRID looks like "gitea:owner/repo#number".
+func (e PullRequestEvent) PRID() string {
+	return forge.PRID(name, fmt.Sprintf("%s#%d", e.Repository.FullName, e.Number))
+}
+
+// Gitea has no ready for review event, a draft is a PR whose title carries
+// one of these prefixes and it becomes ready when an edit removes it.
+var workInProgressPrefixes = []string{"WIP:", "[WIP]"}
+
+func isWorkInProgress(title string) bool {
+	title = strings.ToUpper(strings.TrimSpace(title))
+	for _, prefix := range workInProgressPrefixes {
+		if strings.HasPrefix(title, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e PullRequestEvent) becameReady() bool {
+	return e.Action == "edited" && e.Changes.Title != nil &&
+		isWorkInProgress(e.Changes.Title.From) && !isWorkInProgress(e.PullRequest.Title)
+}
+
+// VerifySignature checks the bare hex HMAC-SHA256 Gitea puts in X-Gitea-Signature.
+func VerifySignature(secret string, body []byte, signature string) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+type Parser struct {
+	secret string
+}
+
+func NewParser(secret string) *Parser {
+	return &Parser{secret: secret}
+}
+
+func (p *Parser) Name() string {
+	return name
+}
+
+func (p *Parser) Verify(r *http.Request, body []byte) bool {
+	return VerifySignature(p.secret, body, r.Header.Get(HeaderSignature))
+}
+
+func (p *Parser) Parse(r *http.Request, body []byte) (forge.Event, bool, error) {
+	if r.Header.Get(HeaderEvent) != "pull_request" {
+		return forge.Event{}, false, nil
+	}
+
+	var event PullRequestEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return forge.Event{}, false, err
+	}
+
+	var action string
+	switch {
+	case event.Action == "opened":
+		action = forge.ActionOpened
+	case event.Action == "closed" && event.PullRequest.Merged:
+		action = forge.ActionMerged
+	case event.Action == "closed":
+		action = forge.ActionClosed
+	case event.Action == "reopened":
+		action = forge.ActionReopened
+	case event.becameReady():
+		action = forge.ActionReady
+	default:
+		return forge.Event{}, false, nil
+	}
+
+	return forge.Event{
+		Action:      action,
+		PRID:        event.PRID(),
+		Title:       event.PullRequest.Title,
+		AuthorLogin: event.PullRequest.User.Login,
+		Draft:       event.PullRequest.Draft || isWorkInProgress(event.PullRequest.Title),
+	}, true, nil
+}
@@ -0,0 +1,73 @@
+package gitea
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"pull_req/pull_req/adapters/forge"
+	"testing"
+)
+
+const testSecret = "gitea-secret"
+
+func fixtureRequest(t *testing.T, fixture string) (*http.Request, []byte) {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write(body)
+
+	r := httptest.NewRequest(http.MethodPost, "/gitea/webhook", bytes.NewReader(body))
+	r.Header.Set(HeaderEvent, "pull_request")
+	r.Header.Set(HeaderSignature, hex.EncodeToString(mac.Sum(nil)))
+	return r, body
+}
+
+func TestParseFixtures(t *testing.T) {
+	const id = "gitea:infra/pull-req#3"
+	tests := []struct {
+		fixture string
+		action  string
+		ok      bool
+	}{
+		{"pull_request_opened.json", forge.ActionOpened, true},
+		{"pull_request_ready.json", forge.ActionReady, true},
+		{"pull_request_label_updated.json", "", false},
+		{"pull_request_closed.json", forge.ActionClosed, true},
+		{"pull_request_reopened.json", forge.ActionReopened, true},
+		{"pull_request_closed_merged.json", forge.ActionMerged, true},
+	}
+
+	p := NewParser(testSecret)
+	for _, tt := range tests {
+		r, body := fixtureRequest(t, tt.fixture)
+		if !p.Verify(r, body) {
+			t.Fatalf("%s: signature rejected", tt.fixture)
+		}
+		event, ok, err := p.Parse(r, body)
+		if err != nil {
+			t.Fatalf("%s: parse failed: %v", tt.fixture, err)
+		}
+		if ok != tt.ok || event.Action != tt.action {
+			t.Fatalf("%s: unexpected event %+v (ok=%v)", tt.fixture, event, ok)
+		}
+		if ok && event.PRID != id {
+			t.Fatalf("%s: unexpected pr id %q", tt.fixture, event.PRID)
+		}
+	}
+}
+
+func TestVerifyRejectsBadSignature(t *testing.T) {
+	r, body := fixtureRequest(t, "pull_request_opened.json")
+	r.Header.Set(HeaderSignature, "deadbeef")
+	if NewParser(testSecret).Verify(r, body) {
+		t.Fatalf("bad signature accepted")
+	}
+}
@@ -0,0 +1,106 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"pull_req/pull_req/core"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const DefaultAPIURL = "https://api.github.com"
+
+var prIDPattern = regexp.MustCompile(`^github:([^/#]+)/([^/#]+)#(\d+)$`)
+
+// Client requests and removes reviewers on GitHub for PRs that came in through
+// the webhook adapter, i.e. whose ids look like "github:owner/repo#number".
+type Client struct {
+	http    *http.Client
+	baseURL string
+	token   string
+	logins  map[string]string
+}
+
+// NewClient takes the same login -> user id mapping as the webhook handler and
+// inverts it, since here we go from our users back to GitHub logins.
+func NewClient(baseURL, token string, logins map[string]string, timeout time.Duration) *Client {
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	byUser := make(map[string]string, len(logins))
+	for login, userID := range logins {
+		byUser[userID] = login
+	}
+	return &Client{
+		http:    &http.Client{Timeout: timeout},
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		logins:  byUser,
+	}
+}
+
+type reviewersReq struct {
+	Reviewers []string `json:"reviewers"`
+}
+
+func (c *Client) RequestReviewers(ctx context.Context, prID string, userIDs []string) error {
+	return c.requestedReviewers(ctx, http.MethodPost, prID, userIDs)
+}
+
+func (c *Client) RemoveRequestedReviewers(ctx context.Context, prID string, userIDs []string) error {
+	return c.requestedReviewers(ctx, http.MethodDelete, prID, userIDs)
+}
+
+func (c *Client) requestedReviewers(ctx context.Context, method, prID string, userIDs []string) error {
+	m := prIDPattern.FindStringSubmatch(prID)
+	if m == nil {
+		return fmt.Errorf("%w: %q is not a github pr", core.ErrNotFound, prID)
+	}
+
+	var logins []string
+	for _, id := range userIDs {
+		if login, ok := c.logins[id]; ok {
+			logins = append(logins, login)
+		}
+	}
+	if len(logins) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(reviewersReq{Reviewers: logins})
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%s/requested_reviewers", c.baseURL, m[1], m[2], m[3])
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: github returned %d", core.ErrNotFound, resp.StatusCode)
+	case resp.StatusCode == http.StatusUnprocessableEntity:
+		return fmt.Errorf("%w: github returned %d", core.ErrForgeRejected, resp.StatusCode)
+	default:
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,93 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"pull_req/pull_req/core"
+	"testing"
+	"time"
+)
+
+type apiCall struct {
+	method    string
+	path      string
+	auth      string
+	reviewers []string
+}
+
+func fakeAPI(t *testing.T, status int) (*httptest.Server, chan apiCall) {
+	t.Helper()
+	calls := make(chan apiCall, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req reviewersReq
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		calls <- apiCall{method: r.Method, path: r.URL.Path, auth: r.Header.Get("Authorization"), reviewers: req.Reviewers}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, calls
+}
+
+func TestClientRequestsAndRemovesReviewers(t *testing.T) {
+	srv, calls := fakeAPI(t, http.StatusCreated)
+	c := NewClient(srv.URL, "token", map[string]string{"octocat": "u1", "hubot": "u2"}, time.Second)
+
+	if err := c.RequestReviewers(context.Background(), "github:octo-org/pull-req#42", []string{"u1", "u3"}); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	got := <-calls
+	if got.method != http.MethodPost || got.path != "/repos/octo-org/pull-req/pulls/42/requested_reviewers" {
+		t.Fatalf("unexpected call %+v", got)
+	}
+	if got.auth != "Bearer token" || len(got.reviewers) != 1 || got.reviewers[0] != "octocat" {
+		t.Fatalf("unexpected call %+v", got)
+	}
+
+	if err := c.RemoveRequestedReviewers(context.Background(), "github:octo-org/pull-req#42", []string{"u2"}); err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+	got = <-calls
+	if got.method != http.MethodDelete || len(got.reviewers) != 1 || got.reviewers[0] != "hubot" {
+		t.Fatalf("unexpected call %+v", got)
+	}
+}
+
+func TestClientSkipsUnknownPRsAndUsers(t *testing.T) {
+	srv, calls := fakeAPI(t, http.StatusCreated)
+	c := NewClient(srv.URL, "token", map[string]string{"octocat": "u1"}, time.Second)
+
+	err := c.RequestReviewers(context.Background(), "pr-1001", []string{"u1"})
+	if !errors.Is(err, core.ErrNotFound) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	// gitea uses the same owner/repo#number form
+	err = c.RequestReviewers(context.Background(), "gitea:octo-org/pull-req#42", []string{"u1"})
+	if !errors.Is(err, core.ErrNotFound) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err = c.RequestReviewers(context.Background(), "github:octo-org/pull-req#42", []string{"u9"}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(calls) != 0 {
+		t.Fatalf("unexpected api calls")
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	srv, _ := fakeAPI(t, http.StatusUnprocessableEntity)
+	c := NewClient(srv.URL, "token", map[string]string{"octocat": "u1"}, time.Second)
+	err := c.RequestReviewers(context.Background(), "github:octo-org/pull-req#42", []string{"u1"})
+	if !errors.Is(err, core.ErrForgeRejected) {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	srv, _ = fakeAPI(t, http.StatusBadGateway)
+	c = NewClient(srv.URL, "token", map[string]string{"octocat": "u1"}, time.Second)
+	err = c.RequestReviewers(context.Background(), "github:octo-org/pull-req#42", []string{"u1"})
+	if err == nil || errors.Is(err, core.ErrForgeRejected) || errors.Is(err, core.ErrNotFound) {
+		t.Fatalf("expected a retryable error, got %v", err)
+	}
+}
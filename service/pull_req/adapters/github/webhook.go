@@ -0,0 +1,114 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"pull_req/pull_req/adapters/forge"
+	"strings"
+)
+
+const (
+	name = "github"
+
+	HeaderEvent     = "X-GitHub-Event"
+	HeaderSignature = "X-Hub-Signature-256"
+)
+
+type User struct {
+	Login string `json:"login"`
+}
+
+type PullRequest struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Draft  bool   `json:"draft"`
+	Merged bool   `json:"merged"`
+	User   User   `json:"user"`
+}
+
+type Repository struct {
+	FullName string `json:"full_name"`
+}
+
+type PullRequestEvent struct {
+	Action      string      `json:"action"`
+	Number      int         `json:"number"`
+	PullRequest PullRequest `json:"pull_request"`
+	Repository  Repository  `json:"repository"`
+}
+
+// PRID builds our pull request id from the repository and the PR number, so
+// redeliveries and later actions on the same PR resolve to the same record.
+// It looks like "github:owner/repo#number".
+func (e PullRequestEvent) PRID() string {
+	return forge.PRID(name, fmt.Sprintf("%s#%d", e.Repository.FullName, e.Number))
+}
+
+func VerifySignature(secret string, body []byte, signature string) bool {
+	sig, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+type Parser struct {
+	secret string
+}
+
+func NewParser(secret string) *Parser {
+	return &Parser{secret: secret}
+}
+
+func (p *Parser) Name() string {
+	return name
+}
+
+func (p *Parser) Verify(r *http.Request, body []byte) bool {
+	return VerifySignature(p.secret, body, r.Header.Get(HeaderSignature))
+}
+
+func (p *Parser) Parse(r *http.Request, body []byte) (forge.Event, bool, error) {
+	if r.Header.Get(HeaderEvent) != "pull_request" {
+		return forge.Event{}, false, nil
+	}
+
+	var event PullRequestEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return forge.Event{}, false, err
+	}
+
+	var action string
+	switch {
+	case event.Action == "opened":
+		action = forge.ActionOpened
+	case event.Action == "closed" && event.PullRequest.Merged:
+		action = forge.ActionMerged
+	case event.Action == "closed":
+		action = forge.ActionClosed
+	case event.Action == "reopened":
+		action = forge.ActionReopened
+	case event.Action == "ready_for_review":
+		action = forge.ActionReady
+	default:
+		return forge.Event{}, false, nil
+	}
+
+	return forge.Event{
+		Action:      action,
+		PRID:        event.PRID(),
+		Title:       event.PullRequest.Title,
+		AuthorLogin: event.PullRequest.User.Login,
+		Draft:       event.PullRequest.Draft,
+	}, true, nil
+}
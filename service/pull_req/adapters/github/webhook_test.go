@@ -0,0 +1,83 @@
+package github
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"pull_req/pull_req/adapters/forge"
+	"testing"
+)
+
+const testSecret = "It's a Secret to Everybody"
+
+func fixtureRequest(t *testing.T, fixture string) (*http.Request, []byte) {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write(body)
+
+	r := httptest.NewRequest(http.MethodPost, "/github/webhook", bytes.NewReader(body))
+	r.Header.Set(HeaderEvent, "pull_request")
+	r.Header.Set(HeaderSignature, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return r, body
+}
+
+func TestParseFixtures(t *testing.T) {
+	const id = "github:octo-org/pull-req#42"
+	tests := []struct {
+		fixture string
+		action  string
+		ok      bool
+	}{
+		{"pull_request_opened.json", forge.ActionOpened, true},
+		{"pull_request_ready_for_review.json", forge.ActionReady, true},
+		{"pull_request_closed.json", forge.ActionClosed, true},
+		{"pull_request_reopened.json", forge.ActionReopened, true},
+		{"pull_request_closed_merged.json", forge.ActionMerged, true},
+		{"pull_request_labeled.json", "", false},
+	}
+
+	p := NewParser(testSecret)
+	for _, tt := range tests {
+		r, body := fixtureRequest(t, tt.fixture)
+		if !p.Verify(r, body) {
+			t.Fatalf("%s: signature rejected", tt.fixture)
+		}
+		event, ok, err := p.Parse(r, body)
+		if err != nil {
+			t.Fatalf("%s: parse failed: %v", tt.fixture, err)
+		}
+		if ok != tt.ok || event.Action != tt.action {
+			t.Fatalf("%s: unexpected event %+v (ok=%v)", tt.fixture, event, ok)
+		}
+		if ok && event.PRID != id {
+			t.Fatalf("%s: unexpected pr id %q", tt.fixture, event.PRID)
+		}
+	}
+
+	r, body := fixtureRequest(t, "pull_request_opened.json")
+	event, _, _ := p.Parse(r, body)
+	if event.AuthorLogin != "octocat" || event.Title != "Add reviewer stats" || !event.Draft {
+		t.Fatalf("unexpected opened event %+v", event)
+	}
+}
+
+func TestVerifyRejectsBadSignature(t *testing.T) {
+	r, body := fixtureRequest(t, "pull_request_opened.json")
+	r.Header.Set(HeaderSignature, "sha256=deadbeef")
+	if NewParser(testSecret).Verify(r, body) {
+		t.Fatalf("bad signature accepted")
+	}
+	r.Header.Del(HeaderSignature)
+	if NewParser(testSecret).Verify(r, body) {
+		t.Fatalf("missing signature accepted")
+	}
+}
@@ -0,0 +1,115 @@
+package gitlab
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"pull_req/pull_req/adapters/forge"
+	"strconv"
+)
+
+const (
+	name = "gitlab"
+
+	HeaderEvent = "X-Gitlab-Event"
+	HeaderToken = "X-Gitlab-Token"
+)
+
+type User struct {
+	Username string `json:"username"`
+}
+
+type Project struct {
+	PathWithNamespace string `json:"path_with_namespace"`
+}
+
+type MergeRequest struct {
+	IID      int    `json:"iid"`
+	Title    string `json:"title"`
+	Action   string `json:"action"`
+	AuthorID int    `json:"author_id"`
+	Draft    bool   `json:"draft"`
+}
+
+type DraftChange struct {
+	Previous bool `json:"previous"`
+	Current  bool `json:"current"`
+}
+
+type Changes struct {
+	Draft *DraftChange `json:"draft"`
+}
+
+type MergeRequestEvent struct {
+	ObjectKind       string       `json:"object_kind"`
+	User             User         `json:"user"`
+	Project          Project      `json:"project"`
+	ObjectAttributes MergeRequest `json:"object_attributes"`
+	Changes          Changes      `json:"changes"`
+}
+
+// PRID uses GitLab's own "group/project!iid" notation for merge requests,
+// prefixed with "gitlab:".
+func (e MergeRequestEvent) PRID() string {
+	return forge.PRID(name, fmt.Sprintf("%s!%d", e.Project.PathWithNamespace, e.ObjectAttributes.IID))
+}
+
+// Parser checks the secret token GitLab sends as is; GitLab does not sign payloads.
+type Parser struct {
+	token string
+}
+
+func NewParser(token string) *Parser {
+	return &Parser{token: token}
+}
+
+func (p *Parser) Name() string {
+	return name
+}
+
+func (p *Parser) Verify(r *http.Request, _ []byte) bool {
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get(HeaderToken)), []byte(p.token)) == 1
+}
+
+func (p *Parser) Parse(r *http.Request, body []byte) (forge.Event, bool, error) {
+	if r.Header.Get(HeaderEvent) != "Merge Request Hook" {
+		return forge.Event{}, false, nil
+	}
+
+	var event MergeRequestEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return forge.Event{}, false, err
+	}
+
+	var action string
+	switch event.ObjectAttributes.Action {
+	case "open":
+		action = forge.ActionOpened
+	case "merge":
+		action = forge.ActionMerged
+	case "close":
+		action = forge.ActionClosed
+	case "reopen":
+		action = forge.ActionReopened
+	case "update":
+		draft := event.Changes.Draft
+		if draft == nil || !draft.Previous || draft.Current {
+			return forge.Event{}, false, nil
+		}
+		action = forge.ActionReady
+	default:
+		return forge.Event{}, false, nil
+	}
+
+	// user is whoever triggered the hook, e.g. the maintainer merging the MR.
+	// The hook only carries the author's numeric id, so GitLab logins are
+	// configured by user id.
+	return forge.Event{
+		Action:      action,
+		PRID:        event.PRID(),
+		Title:       event.ObjectAttributes.Title,
+		AuthorLogin: strconv.Itoa(event.ObjectAttributes.AuthorID),
+		Draft:       event.ObjectAttributes.Draft,
+	}, true, nil
+}
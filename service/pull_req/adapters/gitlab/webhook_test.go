@@ -0,0 +1,82 @@
+package gitlab
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"pull_req/pull_req/adapters/forge"
+	"testing"
+)
+
+const testToken = "gitlab-token"
+
+func fixtureRequest(t *testing.T, fixture string) (*http.Request, []byte) {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/gitlab/webhook", bytes.NewReader(body))
+	r.Header.Set(HeaderEvent, "Merge Request Hook")
+	r.Header.Set(HeaderToken, testToken)
+	return r, body
+}
+
+func TestParseFixtures(t *testing.T) {
+	const id = "gitlab:platform/pull-req!7"
+	tests := []struct {
+		fixture string
+		action  string
+		ok      bool
+	}{
+		{"merge_request_open.json", forge.ActionOpened, true},
+		{"merge_request_open_by_maintainer.json", forge.ActionOpened, true},
+		{"merge_request_ready.json", forge.ActionReady, true},
+		{"merge_request_update.json", "", false},
+		{"merge_request_close.json", forge.ActionClosed, true},
+		{"merge_request_reopen.json", forge.ActionReopened, true},
+		{"merge_request_merge.json", forge.ActionMerged, true},
+	}
+
+	p := NewParser(testToken)
+	for _, tt := range tests {
+		r, body := fixtureRequest(t, tt.fixture)
+		if !p.Verify(r, body) {
+			t.Fatalf("%s: token rejected", tt.fixture)
+		}
+		event, ok, err := p.Parse(r, body)
+		if err != nil {
+			t.Fatalf("%s: parse failed: %v", tt.fixture, err)
+		}
+		if ok != tt.ok || event.Action != tt.action {
+			t.Fatalf("%s: unexpected event %+v (ok=%v)", tt.fixture, event, ok)
+		}
+		if ok && event.PRID != id {
+			t.Fatalf("%s: unexpected pr id %q", tt.fixture, event.PRID)
+		}
+	}
+
+	r, body := fixtureRequest(t, "merge_request_open.json")
+	event, _, _ := p.Parse(r, body)
+	if event.AuthorLogin != "17" || !event.Draft {
+		t.Fatalf("unexpected opened event %+v", event)
+	}
+
+	// the author, not the maintainer who opened the MR for them
+	r, body = fixtureRequest(t, "merge_request_open_by_maintainer.json")
+	event, _, _ = p.Parse(r, body)
+	if event.AuthorLogin != "17" {
+		t.Fatalf("unexpected author %q", event.AuthorLogin)
+	}
+}
+
+func TestVerifyRejectsBadToken(t *testing.T) {
+	r, body := fixtureRequest(t, "merge_request_open.json")
+	r.Header.Set(HeaderToken, "wrong")
+	if NewParser(testToken).Verify(r, body) {
+		t.Fatalf("bad token accepted")
+	}
+}
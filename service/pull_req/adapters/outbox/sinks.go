@@ -0,0 +1,70 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"pull_req/pull_req/core"
+	"strings"
+)
+
+type LogSink struct {
+	log *slog.Logger
+}
+
+func NewLogSink(log *slog.Logger) *LogSink {
+	return &LogSink{log: log}
+}
+
+func (s *LogSink) Name() string {
+	return "log"
+}
+
+func (s *LogSink) Handle(_ context.Context, msg core.OutboxMessage) error {
+	s.log.Info("domain event", "event_id", msg.EventID, "topic", msg.Topic, "payload", string(msg.Payload))
+	return nil
+}
+
+// Publisher matches the publishing side of a NATS connection, so a *nats.Conn
+// (or any broker client wrapped to look like one) can be plugged in directly.
+type Publisher interface {
+	Publish(subject string, data []byte) error
+}
+
+type BrokerSink struct {
+	pub    Publisher
+	prefix string
+}
+
+func NewBrokerSink(pub Publisher, prefix string) *BrokerSink {
+	return &BrokerSink{
+		pub:    pub,
+		prefix: prefix,
+	}
+}
+
+type Envelope struct {
+	ID      string          `json:"id"`
+	Topic   string          `json:"topic"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func (s *BrokerSink) Name() string {
+	return "broker"
+}
+
+func (s *BrokerSink) Subject(topic string) string {
+	return s.prefix + "." + strings.ToLower(topic)
+}
+
+func (s *BrokerSink) Handle(_ context.Context, msg core.OutboxMessage) error {
+	data, err := json.Marshal(Envelope{
+		ID:      msg.EventID,
+		Topic:   msg.Topic,
+		Payload: msg.Payload,
+	})
+	if err != nil {
+		return err
+	}
+	return s.pub.Publish(s.Subject(msg.Topic), data)
+}
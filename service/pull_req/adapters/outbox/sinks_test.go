@@ -0,0 +1,45 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"pull_req/pull_req/core"
+	"testing"
+)
+
+type recorder struct {
+	subject string
+	data    []byte
+}
+
+func (r *recorder) Publish(subject string, data []byte) error {
+	r.subject = subject
+	r.data = data
+	return nil
+}
+
+func TestBrokerSinkPublishesEnvelope(t *testing.T) {
+	rec := &recorder{}
+	err := NewBrokerSink(rec, "pull_req").Handle(context.Background(), core.OutboxMessage{
+		EventID: "5f0c6c1e-5a4b-4c4e-9d1f-0d7f3b0a9e11",
+		Topic:   core.EventMerged,
+		Payload: []byte(`{"pr_id":"pr-1"}`),
+	})
+	if err != nil {
+		t.Fatalf("handle failed: %v", err)
+	}
+
+	if rec.subject != "pull_req.merged" {
+		t.Fatalf("unexpected subject %q", rec.subject)
+	}
+	var env Envelope
+	if err = json.Unmarshal(rec.data, &env); err != nil {
+		t.Fatalf("bad envelope: %v", err)
+	}
+	if env.ID != "5f0c6c1e-5a4b-4c4e-9d1f-0d7f3b0a9e11" || env.Topic != core.EventMerged {
+		t.Fatalf("unexpected envelope %+v", env)
+	}
+	if string(env.Payload) != `{"pr_id":"pr-1"}` {
+		t.Fatalf("unexpected payload %s", env.Payload)
+	}
+}
@@ -1,11 +1,15 @@
 package rest
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"pull_req/pull_req/core"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,6 +20,26 @@ const (
 	codeNotAssigned = "NOT_ASSIGNED"
 	codeNoCandidate = "NO_CANDIDATE"
 	codeNotFound    = "NOT_FOUND"
+
+	codeUnknownStrategy      = "UNKNOWN_STRATEGY"
+	codeInvalidReviewerCount = "INVALID_REVIEWERS_COUNT"
+	codeInvalidApprovals     = "INVALID_APPROVALS_COUNT"
+	codeInvalidReviewState   = "INVALID_REVIEW_STATE"
+	codeInvalidStatus        = "INVALID_STATUS"
+	codeNotApproved          = "NOT_APPROVED"
+	codeChangesRequested     = "CHANGES_REQUESTED"
+	codePrClosed             = "PR_CLOSED"
+	codePrDraft              = "PR_DRAFT"
+	codeInvalidPeriod        = "INVALID_PERIOD"
+	codeNotMember            = "NOT_TEAM_MEMBER"
+	codeMemberOfOtherTeam    = "MEMBER_OF_OTHER_TEAM"
+	codeUnknownMovePolicy    = "UNKNOWN_MOVE_POLICY"
+	codeHasPullRequests      = "USER_HAS_PRS"
+	codeInvalidWebhook       = "INVALID_WEBHOOK"
+	codeInvalidCodeowners    = "INVALID_CODEOWNERS"
+	codeRepositoryExists     = "REPOSITORY_EXISTS"
+	codeInvalidReviewRule    = "INVALID_REVIEW_RULE"
+	codeRuleUnsatisfied      = "RULE_UNSATISFIED"
 )
 
 type ErrorResponse struct {
@@ -42,25 +66,34 @@ type TeamMember struct {
 }
 
 type Team struct {
-	Name    string       `json:"team_name"`
-	Members []TeamMember `json:"members"`
+	Name              string       `json:"team_name"`
+	ReviewerStrategy  string       `json:"reviewer_strategy,omitempty"`
+	ReviewersRequired int          `json:"reviewers_required,omitempty"`
+	ApprovalsRequired int          `json:"approvals_required,omitempty"`
+	Members           []TeamMember `json:"members"`
 }
 
 type TeamResponse struct {
 	Team Team `json:"team"`
 }
 
+type AddTeamReq struct {
+	Team
+	MoveMembers bool `json:"move_members"`
+}
+
 func NewAddTeamHandler(log *slog.Logger, t core.TeamPort) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var team Team
+		var req AddTeamReq
 
-		err := json.NewDecoder(r.Body).Decode(&team)
+		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
 			log.Error("decode body problem", "error", err)
 			http.Error(w, "Bad request body", http.StatusBadRequest)
 			return
 		}
 
+		team := req.Team
 		members := make([]core.TeamMember, len(team.Members))
 		for i, m := range team.Members {
 			members[i].ID = m.ID
@@ -68,9 +101,12 @@ func NewAddTeamHandler(log *slog.Logger, t core.TeamPort) http.HandlerFunc {
 			members[i].IsActive = m.IsActive
 		}
 		err = t.Create(r.Context(), core.Team{
-			Name:    team.Name,
-			Members: members,
-		})
+			Name:              team.Name,
+			ReviewerStrategy:  team.ReviewerStrategy,
+			ReviewersRequired: team.ReviewersRequired,
+			ApprovalsRequired: team.ApprovalsRequired,
+			Members:           members,
+		}, req.MoveMembers)
 		if err != nil {
 			if errors.Is(err, core.ErrAlreadyExists) {
 				log.Error("team already exists", "error", err)
@@ -80,8 +116,41 @@ func NewAddTeamHandler(log *slog.Logger, t core.TeamPort) http.HandlerFunc {
 				}
 				return
 			}
+			if errors.Is(err, core.ErrMemberOfOtherTeam) {
+				log.Error("user is a member of another team", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codeMemberOfOtherTeam, "User is a member of another team")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrUnknownStrategy) {
+				log.Error("unknown strategy", "error", err)
+				err := writeJSONError(w, http.StatusBadRequest, codeUnknownStrategy, "Unknown reviewer strategy")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrInvalidReviewersCount) {
+				log.Error("invalid reviewers count", "error", err)
+				err := writeJSONError(w, http.StatusBadRequest, codeInvalidReviewerCount, "Reviewers count should be positive")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrInvalidApprovalsCount) {
+				log.Error("invalid approvals count", "error", err)
+				err := writeJSONError(w, http.StatusBadRequest, codeInvalidApprovals, "Approvals count should not be negative")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
 			log.Error("create team problem", "error", err)
 			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
 		}
 
 		resp := TeamResponse{Team: team}
@@ -124,8 +193,11 @@ func NewGetTeamHandler(log *slog.Logger, t core.TeamPort) http.HandlerFunc {
 			membersResp[i].IsActive = m.IsActive
 		}
 		teamResp := Team{
-			Name:    team.Name,
-			Members: membersResp,
+			Name:              team.Name,
+			ReviewerStrategy:  team.ReviewerStrategy,
+			ReviewersRequired: team.ReviewersRequired,
+			ApprovalsRequired: team.ApprovalsRequired,
+			Members:           membersResp,
 		}
 		resp := TeamResponse{
 			Team: teamResp,
@@ -137,25 +209,25 @@ func NewGetTeamHandler(log *slog.Logger, t core.TeamPort) http.HandlerFunc {
 	}
 }
 
-type SetIsActiveReq struct {
-	UserID   string `json:"user_id"`
-	IsActive bool   `json:"is_active"`
+type SetStrategyReq struct {
+	TeamName         string `json:"team_name"`
+	ReviewerStrategy string `json:"reviewer_strategy"`
 }
 
-type User struct {
-	ID       string `json:"user_id"`
-	Name     string `json:"username"`
-	TeamName string `json:"team_name"`
-	IsActive bool   `json:"is_active"`
+type TeamSettings struct {
+	Name              string `json:"team_name"`
+	ReviewerStrategy  string `json:"reviewer_strategy"`
+	ReviewersRequired int    `json:"reviewers_required"`
+	ApprovalsRequired int    `json:"approvals_required"`
 }
 
-type UserResponse struct {
-	User User `json:"user"`
+type TeamSettingsResponse struct {
+	Team TeamSettings `json:"team"`
 }
 
-func NewSetIsActiveHandler(log *slog.Logger, u core.UserPort) http.HandlerFunc {
+func NewSetStrategyHandler(log *slog.Logger, t core.TeamPort) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var req SetIsActiveReq
+		var req SetStrategyReq
 
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
@@ -164,11 +236,19 @@ func NewSetIsActiveHandler(log *slog.Logger, u core.UserPort) http.HandlerFunc {
 			return
 		}
 
-		user, err := u.SetFlag(r.Context(), req.UserID, req.IsActive)
+		settings, err := t.SetStrategy(r.Context(), req.TeamName, req.ReviewerStrategy)
 		if err != nil {
+			if errors.Is(err, core.ErrUnknownStrategy) {
+				log.Error("unknown strategy", "error", err)
+				err := writeJSONError(w, http.StatusBadRequest, codeUnknownStrategy, "Unknown reviewer strategy")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
 			if errors.Is(err, core.ErrNotFound) {
-				log.Error("user not found", "error", err)
-				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "User not found")
+				log.Error("team not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "Team not found")
 				if err != nil {
 					log.Error("write json error problem", "error", err)
 				}
@@ -179,48 +259,124 @@ func NewSetIsActiveHandler(log *slog.Logger, u core.UserPort) http.HandlerFunc {
 			return
 		}
 
-		userResp := User{
-			ID:       user.ID,
-			Name:     user.Name,
-			TeamName: user.TeamName,
-			IsActive: user.IsActive,
-		}
-		resp := UserResponse{
-			User: userResp,
+		writeTeamSettings(log, w, settings)
+	}
+}
+
+type UpdateTeamReq struct {
+	TeamName          string  `json:"team_name"`
+	ReviewerStrategy  *string `json:"reviewer_strategy"`
+	ReviewersRequired *int    `json:"reviewers_required"`
+	ApprovalsRequired *int    `json:"approvals_required"`
+}
+
+func NewUpdateTeamHandler(log *slog.Logger, t core.TeamPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req UpdateTeamReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
 		}
 
-		if err = json.NewEncoder(w).Encode(resp); err != nil {
-			log.Error("encoding problem", "error", err)
+		settings, err := t.Update(r.Context(), req.TeamName, core.TeamUpdate{
+			ReviewerStrategy:  req.ReviewerStrategy,
+			ReviewersRequired: req.ReviewersRequired,
+			ApprovalsRequired: req.ApprovalsRequired,
+		})
+		if err != nil {
+			if errors.Is(err, core.ErrUnknownStrategy) {
+				log.Error("unknown strategy", "error", err)
+				err := writeJSONError(w, http.StatusBadRequest, codeUnknownStrategy, "Unknown reviewer strategy")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrInvalidReviewersCount) {
+				log.Error("invalid reviewers count", "error", err)
+				err := writeJSONError(w, http.StatusBadRequest, codeInvalidReviewerCount, "Reviewers count should be positive")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrInvalidApprovalsCount) {
+				log.Error("invalid approvals count", "error", err)
+				err := writeJSONError(w, http.StatusBadRequest, codeInvalidApprovals, "Approvals count should not be negative")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("team not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "Team not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
 		}
+
+		writeTeamSettings(log, w, settings)
 	}
 }
 
-type CreatePRReq struct {
-	PRID     string `json:"pull_request_id"`
-	PRName   string `json:"pull_request_name"`
-	AuthorID string `json:"author_id"`
+func writeTeamSettings(log *slog.Logger, w http.ResponseWriter, settings core.TeamSettings) {
+	resp := TeamSettingsResponse{
+		Team: TeamSettings{
+			Name:              settings.Name,
+			ReviewerStrategy:  settings.ReviewerStrategy,
+			ReviewersRequired: settings.ReviewersRequired,
+			ApprovalsRequired: settings.ApprovalsRequired,
+		},
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Error("encoding problem", "error", err)
+	}
 }
 
-type PullRequestShort struct {
-	ID       string `json:"pull_request_id"`
-	Name     string `json:"pull_request_name"`
-	AuthorID string `json:"author_id"`
-	Status   string `json:"status"`
+type DeactivateUsersReq struct {
+	TeamName string   `json:"team_name"`
+	UserIDs  []string `json:"user_ids"`
+	All      bool     `json:"all"`
 }
 
-type PullRequest struct {
-	PullRequestShort
-	Reviewers []string   `json:"assigned_reviewers"`
-	MergedAt  *time.Time `json:"mergedAt"`
+type UserHandoff struct {
+	UserID string `json:"user_id"`
+	Handoff
 }
 
-type PullRequestResponse struct {
-	PullRequest PullRequest `json:"pull_request"`
+type TeamHandoffResponse struct {
+	TeamName string        `json:"team_name"`
+	Users    []UserHandoff `json:"users"`
 }
 
-func NewCreatePRHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
+func writeTeamHandoff(log *slog.Logger, w http.ResponseWriter, report core.TeamHandoffReport) {
+	resp := TeamHandoffResponse{
+		TeamName: report.TeamName,
+		Users:    make([]UserHandoff, len(report.Users)),
+	}
+	for i, u := range report.Users {
+		resp.Users[i] = UserHandoff{
+			UserID:  u.UserID,
+			Handoff: toHandoff(u.HandoffReport),
+		}
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Error("encoding problem", "error", err)
+	}
+}
+
+func NewDeactivateUsersHandler(log *slog.Logger, t core.TeamPort) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var req CreatePRReq
+		var req DeactivateUsersReq
 
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
@@ -229,19 +385,19 @@ func NewCreatePRHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
 			return
 		}
 
-		pullReq, err := pr.Create(r.Context(), req.PRID, req.PRName, req.AuthorID)
+		report, err := t.DeactivateUsers(r.Context(), req.TeamName, req.UserIDs, req.All)
 		if err != nil {
 			if errors.Is(err, core.ErrNotFound) {
-				log.Error("Author/team not found", "error", err)
-				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "Author/team not found")
+				log.Error("team not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "Team not found")
 				if err != nil {
 					log.Error("write json error problem", "error", err)
 				}
 				return
 			}
-			if errors.Is(err, core.ErrAlreadyExists) {
-				log.Error("pr exists", "error", err)
-				err := writeJSONError(w, http.StatusConflict, codePrExists, "PR exists")
+			if errors.Is(err, core.ErrNotMember) {
+				log.Error("user is not a team member", "error", err)
+				err := writeJSONError(w, http.StatusBadRequest, codeNotMember, "User is not a member of the team")
 				if err != nil {
 					log.Error("write json error problem", "error", err)
 				}
@@ -252,33 +408,18 @@ func NewCreatePRHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
 			return
 		}
 
-		pullReqResp := PullRequest{
-			PullRequestShort: PullRequestShort{
-				ID:       pullReq.ID,
-				Name:     pullReq.Name,
-				AuthorID: pullReq.AuthorID,
-				Status:   pullReq.Status,
-			},
-			Reviewers: pullReq.Reviewers,
-		}
-		resp := PullRequestResponse{
-			PullRequest: pullReqResp,
-		}
-
-		w.WriteHeader(http.StatusCreated)
-		if err = json.NewEncoder(w).Encode(resp); err != nil {
-			log.Error("encoding problem", "error", err)
-		}
+		writeTeamHandoff(log, w, report)
 	}
 }
 
-type MergePRReq struct {
-	PRID string `json:"pull_request_id"`
+type AddMemberReq struct {
+	TeamName string `json:"team_name"`
+	TeamMember
 }
 
-func NewMergePRHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
+func NewAddMemberHandler(log *slog.Logger, t core.TeamPort) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var req MergePRReq
+		var req AddMemberReq
 
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
@@ -287,11 +428,23 @@ func NewMergePRHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
 			return
 		}
 
-		pullReq, err := pr.Merge(r.Context(), req.PRID)
+		team, err := t.AddMember(r.Context(), req.TeamName, core.TeamMember{
+			ID:       req.ID,
+			Name:     req.Name,
+			IsActive: req.IsActive,
+		})
 		if err != nil {
 			if errors.Is(err, core.ErrNotFound) {
-				log.Error("pr not found", "error", err)
-				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "PR not found")
+				log.Error("team not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "Team not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrMemberOfOtherTeam) {
+				log.Error("user is a member of another team", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codeMemberOfOtherTeam, "User is a member of another team")
 				if err != nil {
 					log.Error("write json error problem", "error", err)
 				}
@@ -302,37 +455,36 @@ func NewMergePRHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
 			return
 		}
 
-		pullReqResp := PullRequest{
-			PullRequestShort: PullRequestShort{
-				ID:       pullReq.ID,
-				Name:     pullReq.Name,
-				AuthorID: pullReq.AuthorID,
-				Status:   pullReq.Status,
-			},
-			Reviewers: pullReq.Reviewers,
+		membersResp := make([]TeamMember, len(team.Members))
+		for i, m := range team.Members {
+			membersResp[i].ID = m.ID
+			membersResp[i].Name = m.Name
+			membersResp[i].IsActive = m.IsActive
 		}
-		resp := PullRequestResponse{
-			PullRequest: pullReqResp,
+		resp := TeamResponse{
+			Team: Team{
+				Name:              team.Name,
+				ReviewerStrategy:  team.ReviewerStrategy,
+				ReviewersRequired: team.ReviewersRequired,
+				ApprovalsRequired: team.ApprovalsRequired,
+				Members:           membersResp,
+			},
 		}
+
 		if err = json.NewEncoder(w).Encode(resp); err != nil {
 			log.Error("encoding problem", "error", err)
 		}
 	}
 }
 
-type ReassignPRReq struct {
-	PRID      string `json:"pull_request_id"`
-	OldUserID string `json:"old_user_id"`
-}
-
-type ReassignResponse struct {
-	PR     PullRequest `json:"pr"`
-	NewRev string      `json:"replaced_by"`
+type RemoveMemberReq struct {
+	TeamName string `json:"team_name"`
+	UserID   string `json:"user_id"`
 }
 
-func NewReassignPRHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
+func NewRemoveMemberHandler(log *slog.Logger, t core.TeamPort) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var req ReassignPRReq
+		var req RemoveMemberReq
 
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
@@ -341,35 +493,59 @@ func NewReassignPRHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
 			return
 		}
 
-		pullReq, newRev, err := pr.Reassign(r.Context(), req.PRID, req.OldUserID)
+		report, err := t.RemoveMember(r.Context(), req.TeamName, req.UserID)
 		if err != nil {
-			if errors.Is(err, core.ErrNotFound) {
-				log.Error("pr or user not found", "error", err)
-				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "PR/user not found")
-				if err != nil {
-					log.Error("write json error problem", "error", err)
-				}
-				return
-			}
-			if errors.Is(err, core.ErrAlredyMerged) {
-				log.Error("pr merged", "error", err)
-				err := writeJSONError(w, http.StatusConflict, codePrMerged, "PR merged")
+			if errors.Is(err, core.ErrNotMember) {
+				log.Error("user is not a team member", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotMember, "User is not a member of the team")
 				if err != nil {
 					log.Error("write json error problem", "error", err)
 				}
 				return
 			}
-			if errors.Is(err, core.ErrNotAssigned) {
-				log.Error("not assigned", "error", err)
-				err := writeJSONError(w, http.StatusConflict, codeNotAssigned, "Reviewer is not assigned to this PR")
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		writeTeamHandoff(log, w, report)
+	}
+}
+
+type RenameTeamReq struct {
+	TeamName    string `json:"team_name"`
+	NewTeamName string `json:"new_team_name"`
+}
+
+func NewRenameTeamHandler(log *slog.Logger, t core.TeamPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req RenameTeamReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+		if req.NewTeamName == "" {
+			log.Error("empty or missed new_team_name")
+			http.Error(w, "new_team_name should not be empty", http.StatusBadRequest)
+			return
+		}
+
+		settings, err := t.Rename(r.Context(), req.TeamName, req.NewTeamName)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("team not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "Team not found")
 				if err != nil {
 					log.Error("write json error problem", "error", err)
 				}
 				return
 			}
-			if errors.Is(err, core.ErrNoCandidate) {
-				log.Error("no candidate", "error", err)
-				err := writeJSONError(w, http.StatusConflict, codeNoCandidate, "No active replacement candidate in team")
+			if errors.Is(err, core.ErrAlreadyExists) {
+				log.Error("team already exists", "error", err)
+				err := writeJSONError(w, http.StatusBadRequest, codeTeamExists, "Team already exists")
 				if err != nil {
 					log.Error("write json error problem", "error", err)
 				}
@@ -380,59 +556,1959 @@ func NewReassignPRHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
 			return
 		}
 
-		pullReqResp := PullRequest{
-			PullRequestShort: PullRequestShort{
-				ID:       pullReq.ID,
-				Name:     pullReq.Name,
-				AuthorID: pullReq.AuthorID,
-				Status:   pullReq.Status,
-			},
-			Reviewers: pullReq.Reviewers,
-		}
-		resp := ReassignResponse{
-			PR:     pullReqResp,
-			NewRev: newRev,
-		}
-		if err = json.NewEncoder(w).Encode(resp); err != nil {
-			log.Error("encoding problem", "error", err)
-		}
+		writeTeamSettings(log, w, settings)
 	}
 }
 
-type GetReviewResponse struct {
-	UserID string             `json:"user_id"`
-	PR     []PullRequestShort `json:"pull_requests"`
+type DeleteTeamReq struct {
+	TeamName string `json:"team_name"`
 }
 
-func NewGetReviewHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
+func NewDeleteTeamHandler(log *slog.Logger, t core.TeamPort) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		userID := r.URL.Query().Get("user_id")
-		if userID == "" {
-			log.Error("empty or missed user_id")
-			http.Error(w, "user_id should not be empty", http.StatusBadRequest)
+		var req DeleteTeamReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
 			return
 		}
 
-		prs, err := pr.ListByReviewer(r.Context(), userID)
+		report, err := t.Delete(r.Context(), req.TeamName)
 		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("team not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "Team not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
 			log.Error("internal error", "error", err)
 			http.Error(w, "Internal error", http.StatusInternalServerError)
 			return
 		}
 
-		prsResp := make([]PullRequestShort, len(prs))
-		for i, p := range prs {
-			prsResp[i].ID = p.ID
-			prsResp[i].Name = p.Name
-			prsResp[i].AuthorID = p.AuthorID
-			prsResp[i].Status = p.Status
+		writeTeamHandoff(log, w, report)
+	}
+}
+
+type ReviewRuleReq struct {
+	TeamName     string `json:"team_name"`
+	RequiredTeam string `json:"required_team"`
+	Reviewers    int    `json:"reviewers"`
+}
+
+type ReviewRule struct {
+	RequiredTeam string `json:"required_team"`
+	Reviewers    int    `json:"reviewers"`
+}
+
+type ReviewRulesResponse struct {
+	TeamName string       `json:"team_name"`
+	Rules    []ReviewRule `json:"rules"`
+}
+
+func writeReviewRules(log *slog.Logger, w http.ResponseWriter, name string, rules []core.ReviewRule) {
+	resp := ReviewRulesResponse{
+		TeamName: name,
+		Rules:    make([]ReviewRule, len(rules)),
+	}
+	for i, rule := range rules {
+		resp.Rules[i] = ReviewRule{
+			RequiredTeam: rule.RequiredTeam,
+			Reviewers:    rule.Reviewers,
 		}
-		resp := GetReviewResponse{
-			UserID: userID,
-			PR:     prsResp,
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Error("encoding problem", "error", err)
+	}
+}
+
+func NewSetReviewRuleHandler(log *slog.Logger, t core.TeamPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ReviewRuleReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+		if req.TeamName == "" || req.RequiredTeam == "" {
+			log.Error("empty or missed team_name or required_team")
+			http.Error(w, "team_name and required_team should not be empty", http.StatusBadRequest)
+			return
+		}
+
+		rules, err := t.SetReviewRule(r.Context(), core.ReviewRule{
+			TeamName:     req.TeamName,
+			RequiredTeam: req.RequiredTeam,
+			Reviewers:    req.Reviewers,
+		})
+		if err != nil {
+			if errors.Is(err, core.ErrInvalidReviewRule) {
+				log.Error("invalid review rule", "error", err)
+				err := writeJSONError(w, http.StatusBadRequest, codeInvalidReviewRule, "Reviewers should be positive and required_team should differ from team_name")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("team not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "Team not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		writeReviewRules(log, w, req.TeamName, rules)
+	}
+}
+
+func NewRemoveReviewRuleHandler(log *slog.Logger, t core.TeamPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ReviewRuleReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+
+		rules, err := t.RemoveReviewRule(r.Context(), req.TeamName, req.RequiredTeam)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("review rule not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "Review rule not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		writeReviewRules(log, w, req.TeamName, rules)
+	}
+}
+
+func NewGetReviewRulesHandler(log *slog.Logger, t core.TeamPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("team_name")
+		if name == "" {
+			log.Error("empty or missed team_name")
+			http.Error(w, "team_name should not be empty", http.StatusBadRequest)
+			return
+		}
+
+		rules, err := t.ReviewRules(r.Context(), name)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("team not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "Team not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("get review rules problem", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		writeReviewRules(log, w, name, rules)
+	}
+}
+
+type SetIsActiveReq struct {
+	UserID          string `json:"user_id"`
+	IsActive        bool   `json:"is_active"`
+	ReassignReviews bool   `json:"reassign_reviews"`
+}
+
+type User struct {
+	ID       string `json:"user_id"`
+	Name     string `json:"username"`
+	TeamName string `json:"team_name"`
+	IsActive bool   `json:"is_active"`
+}
+
+type UserResponse struct {
+	User User `json:"user"`
+}
+
+type UsersResponse struct {
+	Users []User `json:"users"`
+}
+
+func toUser(u core.User) User {
+	return User{
+		ID:       u.ID,
+		Name:     u.Name,
+		TeamName: u.TeamName,
+		IsActive: u.IsActive,
+	}
+}
+
+func NewGetUserHandler(log *slog.Logger, u core.UserPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("user_id")
+		if id == "" {
+			log.Error("empty or missed user_id")
+			http.Error(w, "user_id should not be empty", http.StatusBadRequest)
+			return
+		}
+
+		user, err := u.Get(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("user not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "User not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := UserResponse{
+			User: toUser(user),
+		}
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+func NewListUsersHandler(log *slog.Logger, u core.UserPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var filter core.UserFilter
+		query := r.URL.Query()
+		if query.Has("team_name") {
+			teamName := query.Get("team_name")
+			filter.TeamName = &teamName
+		}
+		if query.Has("is_active") {
+			isActive, err := strconv.ParseBool(query.Get("is_active"))
+			if err != nil {
+				log.Error("invalid is_active", "error", err)
+				http.Error(w, "is_active should be true or false", http.StatusBadRequest)
+				return
+			}
+			filter.IsActive = &isActive
+		}
+
+		users, err := u.List(r.Context(), filter)
+		if err != nil {
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := UsersResponse{
+			Users: make([]User, len(users)),
+		}
+		for i, user := range users {
+			resp.Users[i] = toUser(user)
+		}
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+type UpdateUserReq struct {
+	UserID string `json:"user_id"`
+	Name   string `json:"username"`
+}
+
+func NewUpdateUserHandler(log *slog.Logger, u core.UserPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req UpdateUserReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+		if req.Name == "" {
+			log.Error("empty or missed username")
+			http.Error(w, "username should not be empty", http.StatusBadRequest)
+			return
+		}
+
+		user, err := u.Rename(r.Context(), req.UserID, req.Name)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("user not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "User not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := UserResponse{
+			User: toUser(user),
+		}
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+type DeleteUserReq struct {
+	UserID string `json:"user_id"`
+}
+
+type DeleteUserResponse struct {
+	UserID  string  `json:"user_id"`
+	Handoff Handoff `json:"handoff"`
+}
+
+func NewDeleteUserHandler(log *slog.Logger, u core.UserPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req DeleteUserReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+
+		report, err := u.Delete(r.Context(), req.UserID)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("user not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "User not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrHasPullRequests) {
+				log.Error("user has pull requests", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codeHasPullRequests, "User is an author of open pull requests")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := DeleteUserResponse{
+			UserID:  req.UserID,
+			Handoff: toHandoff(report),
+		}
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+type ReassignedPR struct {
+	PRID   string `json:"pull_request_id"`
+	OldRev string `json:"old_user_id"`
+	NewRev string `json:"replaced_by"`
+}
+
+type Handoff struct {
+	Reassigned  []ReassignedPR `json:"reassigned"`
+	NoCandidate []string       `json:"no_candidate"`
+}
+
+type SetIsActiveResponse struct {
+	User    User     `json:"user"`
+	Handoff *Handoff `json:"handoff,omitempty"`
+}
+
+func toHandoff(report core.HandoffReport) Handoff {
+	reassigned := make([]ReassignedPR, len(report.Reassigned))
+	for i, r := range report.Reassigned {
+		reassigned[i] = ReassignedPR{
+			PRID:   r.PRID,
+			OldRev: r.OldReviewerID,
+			NewRev: r.NewReviewerID,
+		}
+	}
+	noCandidate := report.NoCandidate
+	if noCandidate == nil {
+		noCandidate = []string{}
+	}
+	return Handoff{
+		Reassigned:  reassigned,
+		NoCandidate: noCandidate,
+	}
+}
+
+func NewSetIsActiveHandler(log *slog.Logger, u core.UserPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SetIsActiveReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+
+		user, report, err := u.SetFlag(r.Context(), req.UserID, req.IsActive, req.ReassignReviews)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("user not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "User not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := SetIsActiveResponse{
+			User: toUser(user),
+		}
+		if report != nil {
+			handoff := toHandoff(*report)
+			resp.Handoff = &handoff
+		}
+
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+type MoveTeamReq struct {
+	UserID       string `json:"user_id"`
+	TeamName     string `json:"team_name"`
+	ReviewPolicy string `json:"review_policy"`
+}
+
+func NewMoveTeamHandler(log *slog.Logger, u core.UserPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req MoveTeamReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+
+		user, report, err := u.MoveTeam(r.Context(), req.UserID, req.TeamName, req.ReviewPolicy)
+		if err != nil {
+			if errors.Is(err, core.ErrUnknownMovePolicy) {
+				log.Error("unknown move policy", "error", err)
+				err := writeJSONError(w, http.StatusBadRequest, codeUnknownMovePolicy, "Unknown review policy")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("user or team not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "User or team not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := SetIsActiveResponse{
+			User: toUser(user),
+		}
+		if report != nil {
+			handoff := toHandoff(*report)
+			resp.Handoff = &handoff
+		}
+
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+type Absence struct {
+	ID      int64     `json:"absence_id"`
+	UserID  string    `json:"user_id"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	HandOff bool      `json:"hand_off"`
+}
+
+type AbsenceResponse struct {
+	Absence Absence `json:"absence"`
+}
+
+func toAbsence(a core.Absence) Absence {
+	return Absence{
+		ID:      a.ID,
+		UserID:  a.UserID,
+		From:    a.From,
+		To:      a.To,
+		HandOff: a.HandOff,
+	}
+}
+
+type AddAbsenceReq struct {
+	UserID  string    `json:"user_id"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	HandOff bool      `json:"hand_off"`
+}
+
+func NewAddAbsenceHandler(log *slog.Logger, u core.UserPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req AddAbsenceReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+
+		absence, err := u.AddAbsence(r.Context(), core.Absence{
+			UserID:  req.UserID,
+			From:    req.From,
+			To:      req.To,
+			HandOff: req.HandOff,
+		})
+		if err != nil {
+			if errors.Is(err, core.ErrInvalidPeriod) {
+				log.Error("invalid period", "error", err)
+				err := writeJSONError(w, http.StatusBadRequest, codeInvalidPeriod, "Absence should end after it starts")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("user not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "User not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := AbsenceResponse{
+			Absence: toAbsence(absence),
+		}
+		w.WriteHeader(http.StatusCreated)
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+type AbsencesResponse struct {
+	UserID   string    `json:"user_id"`
+	Absences []Absence `json:"absences"`
+}
+
+func NewGetAbsencesHandler(log *slog.Logger, u core.UserPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.URL.Query().Get("user_id")
+		if userID == "" {
+			log.Error("empty or missed user_id")
+			http.Error(w, "user_id should not be empty", http.StatusBadRequest)
+			return
+		}
+
+		absences, err := u.ListAbsences(r.Context(), userID)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("user not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "User not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		absencesResp := make([]Absence, len(absences))
+		for i, a := range absences {
+			absencesResp[i] = toAbsence(a)
+		}
+		resp := AbsencesResponse{
+			UserID:   userID,
+			Absences: absencesResp,
+		}
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+type RemoveAbsenceReq struct {
+	AbsenceID int64 `json:"absence_id"`
+}
+
+func NewRemoveAbsenceHandler(log *slog.Logger, u core.UserPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req RemoveAbsenceReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+
+		absence, err := u.RemoveAbsence(r.Context(), req.AbsenceID)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("absence not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "Absence not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := AbsenceResponse{
+			Absence: toAbsence(absence),
+		}
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+type CreatePRReq struct {
+	PRID           string   `json:"pull_request_id"`
+	PRName         string   `json:"pull_request_name"`
+	AuthorID       string   `json:"author_id"`
+	ReviewersCount int      `json:"reviewers_count"`
+	IsDraft        bool     `json:"is_draft"`
+	Repository     string   `json:"repository"`
+	ChangedFiles   []string `json:"changed_files"`
+}
+
+type PullRequestShort struct {
+	ID       string `json:"pull_request_id"`
+	Name     string `json:"pull_request_name"`
+	AuthorID string `json:"author_id"`
+	Status   string `json:"status"`
+}
+
+type Review struct {
+	ReviewerID string `json:"user_id"`
+	State      string `json:"state"`
+	RuleTeam   string `json:"rule_team,omitempty"`
+}
+
+type PullRequest struct {
+	PullRequestShort
+	Reviewers  []string   `json:"assigned_reviewers"`
+	Reviews    []Review   `json:"reviews,omitempty"`
+	Repository string     `json:"repository,omitempty"`
+	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+	MergedAt   *time.Time `json:"mergedAt"`
+	ClosedAt   *time.Time `json:"closedAt,omitempty"`
+}
+
+type PullRequestResponse struct {
+	PullRequest PullRequest `json:"pull_request"`
+}
+
+func toPullRequest(pullReq core.PullRequest) PullRequest {
+	reviews := make([]Review, len(pullReq.Reviews))
+	for i, r := range pullReq.Reviews {
+		reviews[i].ReviewerID = r.ReviewerID
+		reviews[i].State = r.State
+		reviews[i].RuleTeam = r.RuleTeam
+	}
+	resp := PullRequest{
+		PullRequestShort: PullRequestShort{
+			ID:       pullReq.ID,
+			Name:     pullReq.Name,
+			AuthorID: pullReq.AuthorID,
+			Status:   pullReq.Status,
+		},
+		Reviewers:  pullReq.Reviewers,
+		Reviews:    reviews,
+		Repository: pullReq.Repository,
+		MergedAt:   pullReq.MergedAt,
+		ClosedAt:   pullReq.ClosedAt,
+	}
+	if !pullReq.CreatedAt.IsZero() {
+		resp.CreatedAt = &pullReq.CreatedAt
+	}
+	return resp
+}
+
+func encodeCursor(c *core.Cursor) string {
+	if c == nil {
+		return ""
+	}
+	raw := c.Time.UTC().Format(time.RFC3339Nano) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (*core.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, errors.New("malformed cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, err
+	}
+	return &core.Cursor{Time: t, ID: id}, nil
+}
+
+func parsePageParams(query url.Values) (int, *core.Cursor, error) {
+	var limit int
+	if query.Has("limit") {
+		var err error
+		limit, err = strconv.Atoi(query.Get("limit"))
+		if err != nil || limit < 1 {
+			return 0, nil, errors.New("limit should be a positive number")
+		}
+	}
+
+	var after *core.Cursor
+	if cursor := query.Get("cursor"); cursor != "" {
+		var err error
+		after, err = decodeCursor(cursor)
+		if err != nil {
+			return 0, nil, errors.New("invalid cursor")
+		}
+	}
+	return limit, after, nil
+}
+
+func NewGetPRHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("pull_request_id")
+		if id == "" {
+			log.Error("empty or missed pull_request_id")
+			http.Error(w, "pull_request_id should not be empty", http.StatusBadRequest)
+			return
+		}
+
+		pullReq, err := pr.Get(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("pr not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "PR not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := PullRequestResponse{
+			PullRequest: toPullRequest(pullReq),
+		}
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+type PREvent struct {
+	ID          int64     `json:"event_id"`
+	Type        string    `json:"type"`
+	ActorID     string    `json:"actor_id,omitempty"`
+	OldUserID   string    `json:"old_user_id,omitempty"`
+	NewUserID   string    `json:"new_user_id,omitempty"`
+	OldStatus   string    `json:"old_status,omitempty"`
+	NewStatus   string    `json:"new_status,omitempty"`
+	ReviewState string    `json:"review_state,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type HistoryResponse struct {
+	PRID   string    `json:"pull_request_id"`
+	Events []PREvent `json:"events"`
+}
+
+func NewPRHistoryHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("pull_request_id")
+		if id == "" {
+			log.Error("empty or missed pull_request_id")
+			http.Error(w, "pull_request_id should not be empty", http.StatusBadRequest)
+			return
+		}
+
+		events, err := pr.History(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("pr not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "PR not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := HistoryResponse{
+			PRID:   id,
+			Events: make([]PREvent, len(events)),
+		}
+		for i, e := range events {
+			resp.Events[i] = PREvent{
+				ID:          e.ID,
+				Type:        e.Type,
+				ActorID:     e.ActorID,
+				OldUserID:   e.OldUserID,
+				NewUserID:   e.NewUserID,
+				OldStatus:   e.OldStatus,
+				NewStatus:   e.NewStatus,
+				ReviewState: e.ReviewState,
+				Reason:      e.Reason,
+				CreatedAt:   e.CreatedAt,
+			}
+		}
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+type ListPRResponse struct {
+	PullRequests []PullRequest `json:"pull_requests"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
+}
+
+func NewListPRHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		var filter core.PRFilter
+
+		strParams := map[string]**string{
+			"author_id":   &filter.AuthorID,
+			"reviewer_id": &filter.ReviewerID,
+			"team_name":   &filter.TeamName,
+			"status":      &filter.Status,
+		}
+		for name, dst := range strParams {
+			if query.Has(name) {
+				v := query.Get(name)
+				*dst = &v
+			}
+		}
+
+		timeParams := map[string]**time.Time{
+			"created_from": &filter.CreatedFrom,
+			"created_to":   &filter.CreatedTo,
+			"merged_from":  &filter.MergedFrom,
+			"merged_to":    &filter.MergedTo,
+		}
+		for name, dst := range timeParams {
+			if !query.Has(name) {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, query.Get(name))
+			if err != nil {
+				log.Error("invalid time param", "param", name, "error", err)
+				http.Error(w, name+" should be RFC 3339 time", http.StatusBadRequest)
+				return
+			}
+			t = t.UTC()
+			*dst = &t
+		}
+
+		switch query.Get("order") {
+		case "", "desc":
+		case "asc":
+			filter.Ascending = true
+		default:
+			log.Error("invalid order", "order", query.Get("order"))
+			http.Error(w, "order should be asc or desc", http.StatusBadRequest)
+			return
+		}
+
+		limit, after, err := parsePageParams(query)
+		if err != nil {
+			log.Error("invalid page params", "error", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.Limit, filter.After = limit, after
+
+		page, err := pr.List(r.Context(), filter)
+		if err != nil {
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := ListPRResponse{
+			PullRequests: make([]PullRequest, len(page.PullRequests)),
+			NextCursor:   encodeCursor(page.Next),
+		}
+		for i, p := range page.PullRequests {
+			resp.PullRequests[i] = toPullRequest(p)
+		}
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+func NewCreatePRHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreatePRReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+
+		pullReq, err := pr.Create(r.Context(), core.NewPullRequest{
+			ID:             req.PRID,
+			Name:           req.PRName,
+			AuthorID:       req.AuthorID,
+			ReviewersCount: req.ReviewersCount,
+			Draft:          req.IsDraft,
+			Repository:     req.Repository,
+			ChangedFiles:   req.ChangedFiles,
+		})
+		if err != nil {
+			if errors.Is(err, core.ErrInvalidReviewersCount) {
+				log.Error("invalid reviewers count", "error", err)
+				err := writeJSONError(w, http.StatusBadRequest, codeInvalidReviewerCount, "Reviewers count should be positive")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("Author/team/repository not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "Author/team/repository not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrAlreadyExists) {
+				log.Error("pr exists", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codePrExists, "PR exists")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrRuleUnsatisfied) {
+				log.Error("review rule unsatisfied", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codeRuleUnsatisfied, "Not enough active reviewers for a review rule")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		pullReqResp := toPullRequest(pullReq)
+		resp := PullRequestResponse{
+			PullRequest: pullReqResp,
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+type MergePRReq struct {
+	PRID string `json:"pull_request_id"`
+}
+
+func NewMergePRHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req MergePRReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+
+		pullReq, err := pr.Merge(r.Context(), req.PRID)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("pr not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "PR not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrNotApproved) {
+				log.Error("pr not approved", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codeNotApproved, "PR does not have enough approvals")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrChangesRequested) {
+				log.Error("pr has changes requested", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codeChangesRequested, "A reviewer requested changes")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrPRClosed) {
+				log.Error("pr closed", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codePrClosed, "PR closed")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrPRDraft) {
+				log.Error("pr is a draft", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codePrDraft, "PR is a draft")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		pullReqResp := toPullRequest(pullReq)
+		resp := PullRequestResponse{
+			PullRequest: pullReqResp,
+		}
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+func NewClosePRHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req MergePRReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+
+		pullReq, err := pr.Close(r.Context(), req.PRID)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("pr not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "PR not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrAlredyMerged) {
+				log.Error("pr merged", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codePrMerged, "PR merged")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := PullRequestResponse{
+			PullRequest: toPullRequest(pullReq),
+		}
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+func NewReopenPRHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req MergePRReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+
+		pullReq, err := pr.Reopen(r.Context(), req.PRID)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("pr not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "PR not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrAlredyMerged) {
+				log.Error("pr merged", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codePrMerged, "PR merged")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrPRDraft) {
+				log.Error("pr is a draft", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codePrDraft, "PR is a draft")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrRuleUnsatisfied) {
+				log.Error("review rule unsatisfied", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codeRuleUnsatisfied, "Not enough active reviewers for a review rule")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := PullRequestResponse{
+			PullRequest: toPullRequest(pullReq),
+		}
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+func NewMarkReadyPRHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req MergePRReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+
+		pullReq, err := pr.MarkReady(r.Context(), req.PRID)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("pr not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "PR not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrAlredyMerged) {
+				log.Error("pr merged", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codePrMerged, "PR merged")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrPRClosed) {
+				log.Error("pr closed", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codePrClosed, "PR closed")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrRuleUnsatisfied) {
+				log.Error("review rule unsatisfied", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codeRuleUnsatisfied, "Not enough active reviewers for a review rule")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := PullRequestResponse{
+			PullRequest: toPullRequest(pullReq),
+		}
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+type ReassignPRReq struct {
+	PRID      string `json:"pull_request_id"`
+	OldUserID string `json:"old_user_id"`
+	ActorID   string `json:"actor_id"`
+	Reason    string `json:"reason"`
+}
+
+type ReassignResponse struct {
+	PR     PullRequest `json:"pr"`
+	NewRev string      `json:"replaced_by"`
+}
+
+func NewReassignPRHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ReassignPRReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+
+		pullReq, newRev, err := pr.Reassign(r.Context(), req.PRID, req.OldUserID, req.ActorID, req.Reason)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("pr or user not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "PR/user not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrAlredyMerged) {
+				log.Error("pr merged", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codePrMerged, "PR merged")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrPRClosed) {
+				log.Error("pr closed", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codePrClosed, "PR closed")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrPRDraft) {
+				log.Error("pr is a draft", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codePrDraft, "PR is a draft")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrNotAssigned) {
+				log.Error("not assigned", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codeNotAssigned, "Reviewer is not assigned to this PR")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrNoCandidate) {
+				log.Error("no candidate", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codeNoCandidate, "No active replacement candidate in team")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		pullReqResp := toPullRequest(pullReq)
+		resp := ReassignResponse{
+			PR:     pullReqResp,
+			NewRev: newRev,
+		}
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+type ReviewPRReq struct {
+	PRID       string `json:"pull_request_id"`
+	ReviewerID string `json:"reviewer_id"`
+	State      string `json:"state"`
+}
+
+func NewReviewPRHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ReviewPRReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+
+		pullReq, err := pr.Review(r.Context(), req.PRID, req.ReviewerID, req.State)
+		if err != nil {
+			if errors.Is(err, core.ErrInvalidReviewState) {
+				log.Error("invalid review state", "error", err)
+				err := writeJSONError(w, http.StatusBadRequest, codeInvalidReviewState, "Invalid review state")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("pr not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "PR not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrAlredyMerged) {
+				log.Error("pr merged", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codePrMerged, "PR merged")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrPRClosed) {
+				log.Error("pr closed", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codePrClosed, "PR closed")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrPRDraft) {
+				log.Error("pr is a draft", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codePrDraft, "PR is a draft")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrNotAssigned) {
+				log.Error("not assigned", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codeNotAssigned, "Reviewer is not assigned to this PR")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := PullRequestResponse{
+			PullRequest: toPullRequest(pullReq),
+		}
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+type GetReviewResponse struct {
+	UserID     string             `json:"user_id"`
+	PR         []PullRequestShort `json:"pull_requests"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+func NewGetReviewHandler(log *slog.Logger, pr core.PRPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		userID := query.Get("user_id")
+		if userID == "" {
+			log.Error("empty or missed user_id")
+			http.Error(w, "user_id should not be empty", http.StatusBadRequest)
+			return
+		}
+
+		filter := core.ReviewFilter{ReviewerID: userID}
+		if query.Has("status") {
+			status := query.Get("status")
+			filter.Status = &status
+		}
+		limit, after, err := parsePageParams(query)
+		if err != nil {
+			log.Error("invalid page params", "error", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.Limit, filter.After = limit, after
+
+		page, err := pr.ListByReviewer(r.Context(), filter)
+		if err != nil {
+			if errors.Is(err, core.ErrInvalidStatus) {
+				log.Error("invalid status", "error", err)
+				err := writeJSONError(w, http.StatusBadRequest, codeInvalidStatus, "Status should be OPEN, MERGED, CLOSED or DRAFT")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		prsResp := make([]PullRequestShort, len(page.PullRequests))
+		for i, p := range page.PullRequests {
+			prsResp[i].ID = p.ID
+			prsResp[i].Name = p.Name
+			prsResp[i].AuthorID = p.AuthorID
+			prsResp[i].Status = p.Status
+		}
+		resp := GetReviewResponse{
+			UserID:     userID,
+			PR:         prsResp,
+			NextCursor: encodeCursor(page.Next),
+		}
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+type ReviewerStats struct {
+	UserID                string   `json:"user_id"`
+	Name                  string   `json:"username"`
+	TeamName              string   `json:"team_name"`
+	Assigned              int      `json:"assigned"`
+	Open                  int      `json:"open"`
+	Merged                int      `json:"merged"`
+	ReassignedAway        int      `json:"reassigned_away"`
+	AvgTimeToMergeSeconds *float64 `json:"avg_time_to_merge_seconds"`
+}
+
+type ReviewerStatsResponse struct {
+	Reviewers []ReviewerStats `json:"reviewers"`
+}
+
+func NewReviewerStatsHandler(log *slog.Logger, s core.StatsPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		var filter core.StatsFilter
+		if query.Has("team_name") {
+			teamName := query.Get("team_name")
+			filter.TeamName = &teamName
+		}
+
+		timeParams := map[string]**time.Time{
+			"from": &filter.From,
+			"to":   &filter.To,
+		}
+		for name, dst := range timeParams {
+			if !query.Has(name) {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, query.Get(name))
+			if err != nil {
+				log.Error("invalid time param", "param", name, "error", err)
+				http.Error(w, name+" should be RFC 3339 time", http.StatusBadRequest)
+				return
+			}
+			t = t.UTC()
+			*dst = &t
+		}
+
+		stats, err := s.Reviewers(r.Context(), filter)
+		if err != nil {
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := ReviewerStatsResponse{
+			Reviewers: make([]ReviewerStats, len(stats)),
+		}
+		for i, st := range stats {
+			resp.Reviewers[i] = ReviewerStats{
+				UserID:         st.UserID,
+				Name:           st.Name,
+				TeamName:       st.TeamName,
+				Assigned:       st.Assigned,
+				Open:           st.Open,
+				Merged:         st.Merged,
+				ReassignedAway: st.ReassignedAway,
+			}
+			if st.AvgTimeToMerge != nil {
+				seconds := st.AvgTimeToMerge.Seconds()
+				resp.Reviewers[i].AvgTimeToMergeSeconds = &seconds
+			}
+		}
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+type Webhook struct {
+	ID        int64     `json:"webhook_id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type WebhookResponse struct {
+	Webhook Webhook `json:"webhook"`
+}
+
+type WebhooksResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+type CreateWebhookReq struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+func NewCreateWebhookHandler(log *slog.Logger, wh core.WebhookPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateWebhookReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+
+		hook, err := wh.Create(r.Context(), core.Webhook{
+			URL:    req.URL,
+			Events: req.Events,
+			Secret: req.Secret,
+		})
+		if err != nil {
+			if errors.Is(err, core.ErrInvalidWebhook) {
+				log.Error("invalid webhook", "error", err)
+				err := writeJSONError(w, http.StatusBadRequest, codeInvalidWebhook, "Webhook should have an http(s) url and known events")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		// the secret is shown only once, on creation
+		resp := WebhookResponse{
+			Webhook: Webhook{
+				ID:        hook.ID,
+				URL:       hook.URL,
+				Events:    hook.Events,
+				Secret:    hook.Secret,
+				CreatedAt: hook.CreatedAt,
+			},
+		}
+		w.WriteHeader(http.StatusCreated)
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+func NewListWebhooksHandler(log *slog.Logger, wh core.WebhookPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hooks, err := wh.List(r.Context())
+		if err != nil {
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := WebhooksResponse{
+			Webhooks: make([]Webhook, len(hooks)),
+		}
+		for i, h := range hooks {
+			resp.Webhooks[i] = Webhook{
+				ID:        h.ID,
+				URL:       h.URL,
+				Events:    h.Events,
+				CreatedAt: h.CreatedAt,
+			}
+		}
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+func NewDeleteWebhookHandler(log *slog.Logger, wh core.WebhookPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.URL.Query().Get("webhook_id"), 10, 64)
+		if err != nil {
+			log.Error("invalid webhook_id", "error", err)
+			http.Error(w, "webhook_id should be a number", http.StatusBadRequest)
+			return
+		}
+
+		err = wh.Delete(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("webhook not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "Webhook not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+type CodeownersRule struct {
+	Pattern string   `json:"pattern"`
+	Owners  []string `json:"owners"`
+}
+
+type Codeowners struct {
+	Repository string           `json:"repository"`
+	Rules      []CodeownersRule `json:"rules"`
+}
+
+type CodeownersResponse struct {
+	Codeowners Codeowners `json:"codeowners"`
+}
+
+func toCodeowners(co core.Codeowners) Codeowners {
+	rules := make([]CodeownersRule, len(co.Rules))
+	for i, r := range co.Rules {
+		rules[i] = CodeownersRule{
+			Pattern: r.Pattern,
+			Owners:  r.Owners,
+		}
+	}
+	return Codeowners{
+		Repository: co.Repository,
+		Rules:      rules,
+	}
+}
+
+type SetCodeownersReq struct {
+	Repository string `json:"repository"`
+	Content    string `json:"content"`
+}
+
+func NewSetCodeownersHandler(log *slog.Logger, repos core.RepositoryPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SetCodeownersReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+		if req.Repository == "" {
+			log.Error("empty or missed repository")
+			http.Error(w, "repository should not be empty", http.StatusBadRequest)
+			return
+		}
+
+		co, err := repos.SetCodeowners(r.Context(), req.Repository, req.Content)
+		if err != nil {
+			if errors.Is(err, core.ErrInvalidCodeowners) {
+				log.Error("invalid codeowners", "error", err)
+				err := writeJSONError(w, http.StatusBadRequest, codeInvalidCodeowners, err.Error())
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := CodeownersResponse{
+			Codeowners: toCodeowners(co),
+		}
+		if err = json.NewEncoder(w).Encode(resp); err != nil {
+			log.Error("encoding problem", "error", err)
+		}
+	}
+}
+
+func NewGetCodeownersHandler(log *slog.Logger, repos core.RepositoryPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		repository := r.URL.Query().Get("repository")
+		if repository == "" {
+			log.Error("empty or missed repository")
+			http.Error(w, "repository should not be empty", http.StatusBadRequest)
+			return
+		}
+
+		co, err := repos.GetCodeowners(r.Context(), repository)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("codeowners not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "Codeowners not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := CodeownersResponse{
+			Codeowners: toCodeowners(co),
 		}
 		if err = json.NewEncoder(w).Encode(resp); err != nil {
 			log.Error("encoding problem", "error", err)
 		}
 	}
 }
+
+type Repository struct {
+	Name              string     `json:"repository_name"`
+	OwningTeams       []string   `json:"owning_teams"`
+	ReviewersRequired int        `json:"reviewers_required,omitempty"`
+	ReviewerPool      []string   `json:"reviewer_pool"`
+	CreatedAt         *time.Time `json:"createdAt,omitempty"`
+}
+
+type RepositoryResponse struct {
+	Repository Repository `json:"repository"`
+}
+
+func writeRepository(log *slog.Logger, w http.ResponseWriter, status int, repo core.Repository) {
+	resp := RepositoryResponse{
+		Repository: Repository{
+			Name:              repo.Name,
+			OwningTeams:       append([]string{}, repo.OwningTeams...),
+			ReviewersRequired: repo.ReviewersRequired,
+			ReviewerPool:      append([]string{}, repo.ReviewerPool...),
+		},
+	}
+	if !repo.CreatedAt.IsZero() {
+		resp.Repository.CreatedAt = &repo.CreatedAt
+	}
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Error("encoding problem", "error", err)
+	}
+}
+
+type AddRepositoryReq struct {
+	Name              string   `json:"repository_name"`
+	OwningTeams       []string `json:"owning_teams"`
+	ReviewersRequired int      `json:"reviewers_required"`
+	ReviewerPool      []string `json:"reviewer_pool"`
+}
+
+func NewAddRepositoryHandler(log *slog.Logger, repos core.RepositoryPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req AddRepositoryReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+		if req.Name == "" {
+			log.Error("empty or missed repository_name")
+			http.Error(w, "repository_name should not be empty", http.StatusBadRequest)
+			return
+		}
+
+		repo, err := repos.Create(r.Context(), core.Repository{
+			Name:              req.Name,
+			OwningTeams:       req.OwningTeams,
+			ReviewersRequired: req.ReviewersRequired,
+			ReviewerPool:      req.ReviewerPool,
+		})
+		if err != nil {
+			if errors.Is(err, core.ErrInvalidReviewersCount) {
+				log.Error("invalid reviewers count", "error", err)
+				err := writeJSONError(w, http.StatusBadRequest, codeInvalidReviewerCount, "Reviewers count should not be negative")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrAlreadyExists) {
+				log.Error("repository exists", "error", err)
+				err := writeJSONError(w, http.StatusConflict, codeRepositoryExists, "repository_name already exists")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("team/user not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "Owning team/pool user not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		writeRepository(log, w, http.StatusCreated, repo)
+	}
+}
+
+func NewGetRepositoryHandler(log *slog.Logger, repos core.RepositoryPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("repository_name")
+		if name == "" {
+			log.Error("empty or missed repository_name")
+			http.Error(w, "repository_name should not be empty", http.StatusBadRequest)
+			return
+		}
+
+		repo, err := repos.Get(r.Context(), name)
+		if err != nil {
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("repository not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "Repository not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		writeRepository(log, w, http.StatusOK, repo)
+	}
+}
+
+type UpdateRepositoryReq struct {
+	Name              string    `json:"repository_name"`
+	OwningTeams       *[]string `json:"owning_teams"`
+	ReviewersRequired *int      `json:"reviewers_required"`
+	ReviewerPool      *[]string `json:"reviewer_pool"`
+}
+
+func NewUpdateRepositoryHandler(log *slog.Logger, repos core.RepositoryPort) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req UpdateRepositoryReq
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			log.Error("decode body problem", "error", err)
+			http.Error(w, "Bad request body", http.StatusBadRequest)
+			return
+		}
+
+		repo, err := repos.Update(r.Context(), req.Name, core.RepositoryUpdate{
+			OwningTeams:       req.OwningTeams,
+			ReviewersRequired: req.ReviewersRequired,
+			ReviewerPool:      req.ReviewerPool,
+		})
+		if err != nil {
+			if errors.Is(err, core.ErrInvalidReviewersCount) {
+				log.Error("invalid reviewers count", "error", err)
+				err := writeJSONError(w, http.StatusBadRequest, codeInvalidReviewerCount, "Reviewers count should not be negative")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			if errors.Is(err, core.ErrNotFound) {
+				log.Error("repository/team/user not found", "error", err)
+				err := writeJSONError(w, http.StatusNotFound, codeNotFound, "Repository/owning team/pool user not found")
+				if err != nil {
+					log.Error("write json error problem", "error", err)
+				}
+				return
+			}
+			log.Error("internal error", "error", err)
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		writeRepository(log, w, http.StatusOK, repo)
+	}
+}
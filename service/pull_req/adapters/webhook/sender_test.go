@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"pull_req/pull_req/core"
+	"testing"
+	"time"
+)
+
+func TestSendSignsPayload(t *testing.T) {
+	payload := []byte(`{"event":"pr.created","pull_request_id":"pr-1"}`)
+	received := make(chan *http.Request, 1)
+	var body []byte
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		received <- r
+	}))
+	defer srv.Close()
+
+	err := NewSender(time.Second).Send(context.Background(), core.WebhookDelivery{
+		ID:      7,
+		URL:     srv.URL,
+		Secret:  "secret",
+		Event:   core.WebhookPRCreated,
+		Payload: payload,
+	})
+	if err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+
+	r := <-received
+	if string(body) != string(payload) {
+		t.Fatalf("unexpected body %q", body)
+	}
+	if got := r.Header.Get(HeaderEvent); got != core.WebhookPRCreated {
+		t.Fatalf("unexpected event header %q", got)
+	}
+	if got := r.Header.Get(HeaderDelivery); got != "7" {
+		t.Fatalf("unexpected delivery header %q", got)
+	}
+	if !hmac.Equal([]byte(r.Header.Get(HeaderSignature)), []byte(Sign("secret", body))) {
+		t.Fatalf("signature mismatch")
+	}
+}
+
+func TestSendFailsOnErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	err := NewSender(time.Second).Send(context.Background(), core.WebhookDelivery{
+		URL:     srv.URL,
+		Payload: []byte(`{}`),
+	})
+	if err == nil {
+		t.Fatal("expected an error for 503 response")
+	}
+}
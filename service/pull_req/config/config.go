@@ -8,14 +8,31 @@ import (
 )
 
 type HTTPConfig struct {
-	Address   string `yaml:"pull_req_address" env:"PULL_REQ_ADDRESS" env-default:"localhost:80"`
+	Address string        `yaml:"pull_req_address" env:"PULL_REQ_ADDRESS" env-default:"localhost:80"`
 	Timeout time.Duration `yaml:"timeout" env:"API_TIMEOUT" env-default:"5s"`
 }
 
+type ForgeConfig struct {
+	Secret string            `yaml:"secret" env:"WEBHOOK_SECRET"`
+	Logins map[string]string `yaml:"logins" env:"LOGINS"`
+	Token  string            `yaml:"token" env:"TOKEN"`
+	APIURL string            `yaml:"api_url" env:"API_URL"`
+}
+
 type Config struct {
-	LogLevel  string `yaml:"log_level" env:"LOG_LEVEL" env-default:"DEBUG"`
+	LogLevel   string `yaml:"log_level" env:"LOG_LEVEL" env-default:"DEBUG"`
 	HTTPConfig `yaml:"pull_req_server"`
-	DBAddress string `yaml:"db_address" env:"DB_ADDRESS" env-default:"localhost:81"`
+	DBAddress  string `yaml:"db_address" env:"DB_ADDRESS" env-default:"localhost:81"`
+
+	AbsenceCheckInterval time.Duration `yaml:"absence_check_interval" env:"ABSENCE_CHECK_INTERVAL" env-default:"1m"`
+	FallbackTeam         string        `yaml:"fallback_team" env:"FALLBACK_TEAM"`
+	WebhookInterval      time.Duration `yaml:"webhook_interval" env:"WEBHOOK_INTERVAL" env-default:"5s"`
+	WebhookTimeout       time.Duration `yaml:"webhook_timeout" env:"WEBHOOK_TIMEOUT" env-default:"5s"`
+	OutboxInterval       time.Duration `yaml:"outbox_interval" env:"OUTBOX_INTERVAL" env-default:"1s"`
+
+	Github ForgeConfig `yaml:"github" env-prefix:"GITHUB_"`
+	Gitlab ForgeConfig `yaml:"gitlab" env-prefix:"GITLAB_"`
+	Gitea  ForgeConfig `yaml:"gitea" env-prefix:"GITEA_"`
 }
 
 func MustLoad(configPath string) Config {
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type CodeownersRule struct {
+	Pattern string
+	Owners  []string
+	re      *regexp.Regexp
+}
+
+type Codeowners struct {
+	Repository string
+	Rules      []CodeownersRule
+}
+
+// ParseCodeowners reads a CODEOWNERS file with GitHub's semantics: gitignore
+// style patterns, the last matching rule wins and a rule without owners
+// unassigns the paths it matches. Negation and character ranges are not
+// supported by GitHub, so they are rejected here as well.
+func ParseCodeowners(content string) (Codeowners, error) {
+	var co Codeowners
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		re, err := compileCodeownersPattern(fields[0])
+		if err != nil {
+			return Codeowners{}, fmt.Errorf("%w: line %d: %v", ErrInvalidCodeowners, n, err)
+		}
+		for _, owner := range fields[1:] {
+			if !strings.Contains(owner, "@") {
+				return Codeowners{}, fmt.Errorf("%w: line %d: bad owner %q", ErrInvalidCodeowners, n, owner)
+			}
+		}
+		co.Rules = append(co.Rules, CodeownersRule{
+			Pattern: fields[0],
+			Owners:  fields[1:],
+			re:      re,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return Codeowners{}, fmt.Errorf("%w: %v", ErrInvalidCodeowners, err)
+	}
+	return co, nil
+}
+
+func compileCodeownersPattern(pattern string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(pattern, "!") {
+		return nil, fmt.Errorf("negated pattern %q", pattern)
+	}
+	if strings.ContainsAny(pattern, "[]") {
+		return nil, fmt.Errorf("character range in %q", pattern)
+	}
+
+	p := pattern
+	// a slash at the start or in the middle anchors the pattern to the root
+	anchored := strings.Contains(strings.TrimSuffix(p, "/"), "/")
+	p = strings.TrimPrefix(p, "/")
+	dirOnly := strings.HasSuffix(p, "/")
+	p = strings.TrimSuffix(p, "/")
+	if p == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	var b strings.Builder
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("^(?:.*/)?")
+	}
+
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		last := i == len(segments)-1
+		switch {
+		case seg == "**" && last:
+			b.WriteString(".*")
+			continue
+		case seg == "**":
+			b.WriteString("(?:.*/)?")
+			continue
+		}
+		for _, r := range seg {
+			switch r {
+			case '*':
+				b.WriteString("[^/]*")
+			case '?':
+				b.WriteString("[^/]")
+			default:
+				b.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		if !last {
+			b.WriteString("/")
+		}
+	}
+
+	last := segments[len(segments)-1]
+	switch {
+	case last == "**":
+	case dirOnly:
+		b.WriteString("/.*")
+	case !strings.ContainsAny(last, "*?"):
+		// a plain name may be a directory, which owns everything below it
+		b.WriteString("(?:/.*)?")
+	}
+	b.WriteString("$")
+
+	return regexp.Compile(b.String())
+}
+
+// Owners returns the owners of the last rule matching path, nil if none does.
+func (co Codeowners) Owners(path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	for i := len(co.Rules) - 1; i >= 0; i-- {
+		if co.Rules[i].re.MatchString(path) {
+			return co.Rules[i].Owners
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// the example file from GitHub's CODEOWNERS documentation
+const exampleCodeowners = `
+# These owners will be the default owners for everything in
+# the repo. Unless a later match takes precedence.
+*       @global-owner1 @global-owner2
+
+# Order is important; the last matching pattern takes the most precedence.
+*.js    @js-owner #This is an inline comment.
+*.go docs@example.com
+
+# Teams can be specified as code owners as well.
+*.txt @octo-org/octocats
+
+/build/logs/ @doctocat
+
+# The docs/* pattern will match files like docs/getting-started.md
+# but not further nested files like docs/build-app/troubleshooting.md.
+docs/*  docs@example.com
+
+apps/ @octocat
+/docs/ @doctocat
+/scripts/ @doctocat @octocat
+**/logs @octocat
+
+# In this example, @octocat owns any file in the /apps directory in the
+# root of your repository except for the /apps/github subdirectory, as
+# its owners are left empty.
+/apps/ @octocat
+/apps/github
+`
+
+func TestCodeownersOwners(t *testing.T) {
+	co, err := ParseCodeowners(exampleCodeowners)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	tests := []struct {
+		path   string
+		owners []string
+	}{
+		{"README.md", []string{"@global-owner1", "@global-owner2"}},
+		{"web/src/index.js", []string{"@js-owner"}},
+		{"cmd/main.go", []string{"docs@example.com"}},
+		{"notes/todo.txt", []string{"@octo-org/octocats"}},
+		{"build/logs/out.log", []string{"@octocat"}},
+		{"docs/getting-started.md", []string{"@doctocat"}},
+		{"docs/build-app/troubleshooting.md", []string{"@doctocat"}},
+		{"src/docs/readme.md", []string{"@global-owner1", "@global-owner2"}},
+		{"src/docs/setup.md", []string{"@global-owner1", "@global-owner2"}},
+		{"deep/apps/config.yaml", []string{"@octocat"}},
+		{"scripts/deploy.sh", []string{"@doctocat", "@octocat"}},
+		{"deeply/nested/logs/app.log", []string{"@octocat"}},
+		{"apps/web/main.go", []string{"@octocat"}},
+		{"apps/github/main.go", nil},
+		{"/apps/github", nil},
+	}
+	for _, tt := range tests {
+		if got := co.Owners(tt.path); !slices.Equal(got, tt.owners) {
+			t.Errorf("%s: got %v, want %v", tt.path, got, tt.owners)
+		}
+	}
+}
+
+func TestCodeownersPatterns(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"docs/*", "docs/a.md", true},
+		{"docs/*", "docs/a/b.md", false},
+		{"docs/*", "src/docs/a.md", false},
+		{"docs/", "src/docs/a/b.md", true},
+		{"/docs/", "src/docs/a.md", false},
+		{"docs/**", "docs/a/b.md", true},
+		{"a/**/b.md", "a/b.md", true},
+		{"a/**/b.md", "a/x/y/b.md", true},
+		{"*.md", "x/y.md", true},
+		{"file?.go", "pkg/file1.go", true},
+		{"file?.go", "pkg/file10.go", false},
+		{"/Makefile", "Makefile", true},
+		{"/Makefile", "sub/Makefile", false},
+		{"vendor", "vendor/lib/a.go", true},
+		{"vendor", "src/vendor/lib/a.go", true},
+	}
+	for _, tt := range tests {
+		co, err := ParseCodeowners(tt.pattern + " @owner")
+		if err != nil {
+			t.Fatalf("%s: parse failed: %v", tt.pattern, err)
+		}
+		if got := co.Owners(tt.path) != nil; got != tt.match {
+			t.Errorf("%s on %s: got %v, want %v", tt.pattern, tt.path, got, tt.match)
+		}
+	}
+}
+
+func TestCodeownersRejectsUnsupported(t *testing.T) {
+	for _, content := range []string{
+		"!docs/ @owner",
+		"[Dd]ocs/ @owner",
+		"docs/ owner",
+	} {
+		if _, err := ParseCodeowners(content); !errors.Is(err, ErrInvalidCodeowners) {
+			t.Errorf("%q: expected ErrInvalidCodeowners, got %v", content, err)
+		}
+	}
+}
@@ -7,3 +7,22 @@ var ErrNotFound = errors.New("resource is not found")
 var ErrAlredyMerged = errors.New("pr merged")
 var ErrNotAssigned = errors.New("user not a reviewer")
 var ErrNoCandidate = errors.New("no candidates")
+var ErrUnknownStrategy = errors.New("unknown reviewer strategy")
+var ErrInvalidReviewersCount = errors.New("invalid reviewers count")
+var ErrInvalidApprovalsCount = errors.New("invalid approvals count")
+var ErrInvalidReviewState = errors.New("invalid review state")
+var ErrInvalidStatus = errors.New("invalid pr status")
+var ErrNotApproved = errors.New("not enough approvals")
+var ErrChangesRequested = errors.New("changes requested")
+var ErrPRClosed = errors.New("pr closed")
+var ErrPRDraft = errors.New("pr is a draft")
+var ErrInvalidPeriod = errors.New("invalid period")
+var ErrNotMember = errors.New("user is not a team member")
+var ErrMemberOfOtherTeam = errors.New("user is a member of another team")
+var ErrUnknownMovePolicy = errors.New("unknown move policy")
+var ErrHasPullRequests = errors.New("user is an author of open pull requests")
+var ErrInvalidWebhook = errors.New("invalid webhook")
+var ErrForgeRejected = errors.New("forge rejected the request")
+var ErrInvalidCodeowners = errors.New("invalid codeowners")
+var ErrInvalidReviewRule = errors.New("invalid review rule")
+var ErrRuleUnsatisfied = errors.New("not enough reviewers for review rule")
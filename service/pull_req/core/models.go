@@ -2,6 +2,66 @@ package core
 
 import "time"
 
+const DefaultReviewersRequired = 2
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 100
+)
+
+const (
+	StatusOpen   = "OPEN"
+	StatusMerged = "MERGED"
+	StatusClosed = "CLOSED"
+	StatusDraft  = "DRAFT"
+)
+
+const (
+	ReviewPending          = "PENDING"
+	ReviewApproved         = "APPROVED"
+	ReviewChangesRequested = "CHANGES_REQUESTED"
+	ReviewCommented        = "COMMENTED"
+)
+
+const (
+	MoveKeepReviews = "KEEP"
+	MoveReassignOld = "REASSIGN_OLD_TEAM"
+	MoveReassignNew = "REASSIGN_NEW_TEAM"
+)
+
+const (
+	EventCreated       = "CREATED"
+	EventAssigned      = "ASSIGNED"
+	EventReassigned    = "REASSIGNED"
+	EventReviewed      = "REVIEWED"
+	EventMerged        = "MERGED"
+	EventStatusChanged = "STATUS_CHANGED"
+)
+
+const ReasonHandoff = "handoff"
+
+const (
+	WebhookPRCreated          = "pr.created"
+	WebhookReviewerAssigned   = "reviewer.assigned"
+	WebhookReviewerReassigned = "reviewer.reassigned"
+	WebhookPRMerged           = "pr.merged"
+)
+
+const (
+	WebhookBatchSize   = 20
+	WebhookMaxAttempts = 10
+	WebhookBaseBackoff = 10 * time.Second
+	WebhookMaxBackoff  = time.Hour
+	WebhookLease       = time.Minute
+)
+
+const (
+	OutboxBatchSize   = 50
+	OutboxBaseBackoff = 5 * time.Second
+	OutboxMaxBackoff  = time.Hour
+	OutboxLease       = time.Minute
+)
+
 type TeamMember struct {
 	ID       string
 	Name     string
@@ -9,8 +69,48 @@ type TeamMember struct {
 }
 
 type Team struct {
-	Name    string
-	Members []TeamMember
+	Name              string
+	ReviewerStrategy  string
+	ReviewersRequired int
+	ApprovalsRequired int
+	Members           []TeamMember
+}
+
+type TeamSettings struct {
+	Name              string
+	ReviewerStrategy  string
+	ReviewersRequired int
+	ApprovalsRequired int
+}
+
+type TeamUpdate struct {
+	ReviewerStrategy  *string
+	ReviewersRequired *int
+	ApprovalsRequired *int
+}
+
+// Repository settings override the author's team: ReviewersRequired when it is
+// set, and ReviewerPool replaces the team as the set of candidates.
+type Repository struct {
+	Name              string
+	OwningTeams       []string
+	ReviewersRequired int
+	ReviewerPool      []string
+	CreatedAt         time.Time
+}
+
+// ReviewRule makes every PR of TeamName need Reviewers more reviewers from
+// RequiredTeam, on top of the team's own ones.
+type ReviewRule struct {
+	TeamName     string
+	RequiredTeam string
+	Reviewers    int
+}
+
+type RepositoryUpdate struct {
+	OwningTeams       *[]string
+	ReviewersRequired *int
+	ReviewerPool      *[]string
 }
 
 type User struct {
@@ -20,15 +120,166 @@ type User struct {
 	IsActive bool
 }
 
+type UserFilter struct {
+	TeamName *string
+	IsActive *bool
+}
+
+type Absence struct {
+	ID      int64
+	UserID  string
+	From    time.Time
+	To      time.Time
+	HandOff bool
+}
+
 type PullRequestShort struct {
-	ID       string
-	Name     string
-	AuthorID string
-	Status   string
+	ID        string
+	Name      string
+	AuthorID  string
+	Status    string
+	CreatedAt time.Time
+}
+
+type NewPullRequest struct {
+	ID             string
+	Name           string
+	AuthorID       string
+	ReviewersCount int
+	Draft          bool
+	Repository     string
+	ChangedFiles   []string
+}
+
+type Review struct {
+	ReviewerID string
+	State      string
+	RuleTeam   string
+}
+
+type Reassignment struct {
+	PRID          string
+	OldReviewerID string
+	NewReviewerID string
+}
+
+type HandoffReport struct {
+	Reassigned  []Reassignment
+	NoCandidate []string
+}
+
+type UserHandoff struct {
+	UserID string
+	HandoffReport
+}
+
+type TeamHandoffReport struct {
+	TeamName string
+	Users    []UserHandoff
 }
 
 type PullRequest struct {
 	PullRequestShort
-	Reviewers []string
-	MergedAt  *time.Time
+	Reviewers         []string
+	Reviews           []Review
+	RuleTeams         map[string]string // reviewer id -> team of the review rule
+	ReviewersCount    int
+	ApprovalsRequired int // the author's team setting when the PR was created
+	Repository        string
+	ChangedFiles      []string
+	MergedAt          *time.Time
+	ClosedAt          *time.Time
+}
+
+type PREvent struct {
+	ID          int64
+	PRID        string
+	Type        string
+	ActorID     string
+	OldUserID   string
+	NewUserID   string
+	OldStatus   string
+	NewStatus   string
+	ReviewState string
+	Reason      string
+	CreatedAt   time.Time
+}
+
+type Webhook struct {
+	ID        int64
+	URL       string
+	Secret    string
+	Events    []string
+	CreatedAt time.Time
+}
+
+type OutboxMessage struct {
+	ID       int64
+	EventID  string
+	Topic    string
+	Payload  []byte
+	Attempts int
+}
+
+type WebhookDelivery struct {
+	ID        int64
+	WebhookID int64
+	URL       string
+	Secret    string
+	Event     string
+	Payload   []byte
+	Attempts  int
+}
+
+type Cursor struct {
+	Time time.Time
+	ID   string
+}
+
+type PRFilter struct {
+	AuthorID    *string
+	ReviewerID  *string
+	TeamName    *string
+	Status      *string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	MergedFrom  *time.Time
+	MergedTo    *time.Time
+	Ascending   bool
+	Limit       int
+	After       *Cursor
+}
+
+type PRPage struct {
+	PullRequests []PullRequest
+	Next         *Cursor
+}
+
+type StatsFilter struct {
+	TeamName *string
+	From     *time.Time
+	To       *time.Time
+}
+
+type ReviewerStats struct {
+	UserID         string
+	Name           string
+	TeamName       string
+	Assigned       int
+	Open           int
+	Merged         int
+	ReassignedAway int
+	AvgTimeToMerge *time.Duration
+}
+
+type ReviewFilter struct {
+	ReviewerID string
+	Status     *string
+	Limit      int
+	After      *Cursor
+}
+
+type ReviewPage struct {
+	PullRequests []PullRequestShort
+	Next         *Cursor
 }
@@ -1,37 +1,167 @@
 package core
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type TeamPort interface {
-	Create(context.Context, Team) error
+	Create(ctx context.Context, team Team, moveMembers bool) error
 	Get(ctx context.Context, name string) (Team, error)
+	SetStrategy(ctx context.Context, name, strategy string) (TeamSettings, error)
+	Update(ctx context.Context, name string, upd TeamUpdate) (TeamSettings, error)
+	DeactivateUsers(ctx context.Context, name string, userIDs []string, all bool) (TeamHandoffReport, error)
+	AddMember(ctx context.Context, name string, member TeamMember) (Team, error)
+	RemoveMember(ctx context.Context, name, userID string) (TeamHandoffReport, error)
+	Rename(ctx context.Context, name, newName string) (TeamSettings, error)
+	Delete(ctx context.Context, name string) (TeamHandoffReport, error)
+	SetReviewRule(ctx context.Context, rule ReviewRule) ([]ReviewRule, error)
+	RemoveReviewRule(ctx context.Context, name, requiredTeam string) ([]ReviewRule, error)
+	ReviewRules(ctx context.Context, name string) ([]ReviewRule, error)
 }
 
 type UserPort interface {
-	SetFlag(ctx context.Context, id string, isActive bool) (User, error)
+	Get(ctx context.Context, id string) (User, error)
+	List(ctx context.Context, filter UserFilter) ([]User, error)
+	Rename(ctx context.Context, id, name string) (User, error)
+	Delete(ctx context.Context, id string) (HandoffReport, error)
+	SetFlag(ctx context.Context, id string, isActive, reassignReviews bool) (User, *HandoffReport, error)
+	AddAbsence(ctx context.Context, absence Absence) (Absence, error)
+	ListAbsences(ctx context.Context, userID string) ([]Absence, error)
+	RemoveAbsence(ctx context.Context, id int64) (Absence, error)
+	MoveTeam(ctx context.Context, id, teamName, policy string) (User, *HandoffReport, error)
+}
+
+type RepositoryPort interface {
+	Create(ctx context.Context, repo Repository) (Repository, error)
+	Get(ctx context.Context, name string) (Repository, error)
+	Update(ctx context.Context, name string, upd RepositoryUpdate) (Repository, error)
+	SetCodeowners(ctx context.Context, repository, content string) (Codeowners, error)
+	GetCodeowners(ctx context.Context, repository string) (Codeowners, error)
 }
 
 type PRPort interface {
-	Create(ctx context.Context, prID, name, authorID string) (PullRequest, error)
+	Create(ctx context.Context, newPR NewPullRequest) (PullRequest, error)
 	Merge(ctx context.Context, id string) (PullRequest, error)
-	Reassign(ctx context.Context, prID, oldReviewerID string) (PullRequest, string, error)
-	ListByReviewer(ctx context.Context, reviewerID string) ([]PullRequestShort, error)
+	RecordMerge(ctx context.Context, id string) (PullRequest, error)
+	Reassign(ctx context.Context, prID, oldReviewerID, actorID, reason string) (PullRequest, string, error)
+	Review(ctx context.Context, prID, reviewerID, state string) (PullRequest, error)
+	Close(ctx context.Context, id string) (PullRequest, error)
+	Reopen(ctx context.Context, id string) (PullRequest, error)
+	MarkReady(ctx context.Context, id string) (PullRequest, error)
+	HandOff(ctx context.Context, reviewerID, fallbackTeam string) (HandoffReport, error)
+	ListByReviewer(ctx context.Context, filter ReviewFilter) (ReviewPage, error)
+	Get(ctx context.Context, id string) (PullRequest, error)
+	List(ctx context.Context, filter PRFilter) (PRPage, error)
+	History(ctx context.Context, id string) ([]PREvent, error)
+}
+
+type WebhookPort interface {
+	Create(ctx context.Context, hook Webhook) (Webhook, error)
+	List(ctx context.Context) ([]Webhook, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+type EventSink interface {
+	Name() string
+	Handle(ctx context.Context, msg OutboxMessage) error
+}
+
+type WebhookSender interface {
+	Send(ctx context.Context, delivery WebhookDelivery) error
+}
+
+type ForgeClient interface {
+	RequestReviewers(ctx context.Context, prID string, userIDs []string) error
+	RemoveRequestedReviewers(ctx context.Context, prID string, userIDs []string) error
+}
+
+type StatsPort interface {
+	Reviewers(ctx context.Context, filter StatsFilter) ([]ReviewerStats, error)
 }
 
 type TeamDB interface {
-	Add(context.Context, Team) error
+	Add(ctx context.Context, team Team, moveMembers bool) error
 	Get(ctx context.Context, name string) (Team, error)
+	UpdateSettings(ctx context.Context, name string, upd TeamUpdate) (TeamSettings, error)
+	DeactivateMembers(ctx context.Context, name string, userIDs []string) error
+	AddMember(ctx context.Context, name string, member TeamMember) error
+	RemoveMember(ctx context.Context, name, userID string) error
+	Rename(ctx context.Context, name, newName string) (TeamSettings, error)
+	Delete(ctx context.Context, name string) error
+	SetReviewRule(ctx context.Context, rule ReviewRule) error
+	DeleteReviewRule(ctx context.Context, name, requiredTeam string) error
+	GetReviewRules(ctx context.Context, name string) ([]ReviewRule, error)
 }
 
 type UserDB interface {
+	Get(ctx context.Context, id string) (User, error)
+	List(ctx context.Context, filter UserFilter) ([]User, error)
+	UpdateName(ctx context.Context, id, name string) (User, error)
+	Delete(ctx context.Context, id string) error
 	UpdateIsActive(ctx context.Context, id string, isActive bool) (User, error)
+	AddAbsence(ctx context.Context, absence Absence) (Absence, error)
+	GetAbsences(ctx context.Context, userID string) ([]Absence, error)
+	DeleteAbsence(ctx context.Context, id int64) (Absence, error)
+	TakeStartedHandoffs(ctx context.Context) ([]Absence, error)
+	UpdateTeam(ctx context.Context, id, teamName string) (User, error)
+}
+
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type PRDB interface {
 	Get(ctx context.Context, id string) (PullRequest, error)
-	GetActiveTeamMemberIDsByUserID(ctx context.Context, userID string) ([]string, error)
-	Add(ctx context.Context, prID, name, authorID string, reviewersID []string) error
+	GetForUpdate(ctx context.Context, id string) (PullRequest, error)
+	GetTeamSettings(ctx context.Context, teamName string) (TeamSettings, error)
+	GetTeamSettingsByUserID(ctx context.Context, userID string) (TeamSettings, error)
+	GetActiveTeamMemberIDs(ctx context.Context, teamName string) ([]string, error)
+	GetActiveUserIDs(ctx context.Context, userIDs []string) ([]string, error)
+	GetCodeowners(ctx context.Context, repository string) (string, error)
+	GetRepository(ctx context.Context, name string) (Repository, error)
+	GetReviewRules(ctx context.Context, teamName string) ([]ReviewRule, error)
+	GetOpenReviewCounts(ctx context.Context, userIDs []string) (map[string]int, error)
+	GetRoundRobinLast(ctx context.Context, teamName, repository string) (string, error)
+	SetRoundRobinLast(ctx context.Context, teamName, repository, userID string) error
+	Add(ctx context.Context, pullReq PullRequest) error
+	AddReviewers(ctx context.Context, prID string, reviewersID []string, ruleTeams map[string]string) error
 	UpdateMerged(ctx context.Context, id string) (PullRequest, error)
+	UpdateStatus(ctx context.Context, id, status string) (PullRequest, error)
 	UpdateReviewer(ctx context.Context, prID, oldReviewerID, newReviewerID string) (PullRequest, error)
-	GetByReviewer(ctx context.Context, reviewerID string) ([]PullRequestShort, error)
+	UpdateReviewState(ctx context.Context, prID, reviewerID, state string) error
+	GetByReviewer(ctx context.Context, filter ReviewFilter) ([]PullRequestShort, error)
+	GetOpenIDsByReviewer(ctx context.Context, reviewerID string) ([]string, error)
+	List(ctx context.Context, filter PRFilter) ([]PullRequest, error)
+	AddEvents(ctx context.Context, events []PREvent) error
+	GetEvents(ctx context.Context, prID string) ([]PREvent, error)
+}
+
+type RepositoryDB interface {
+	SetCodeowners(ctx context.Context, repository, content string) error
+	GetCodeowners(ctx context.Context, repository string) (string, error)
+	Add(ctx context.Context, repo Repository) (Repository, error)
+	Get(ctx context.Context, name string) (Repository, error)
+	Update(ctx context.Context, name string, upd RepositoryUpdate) (Repository, error)
+}
+
+type StatsDB interface {
+	GetReviewerStats(ctx context.Context, filter StatsFilter) ([]ReviewerStats, error)
+}
+
+type WebhookDB interface {
+	Add(ctx context.Context, hook Webhook) (Webhook, error)
+	List(ctx context.Context) ([]Webhook, error)
+	Delete(ctx context.Context, id int64) error
+	Enqueue(ctx context.Context, eventID, event string, payload []byte) error
+	TakeDue(ctx context.Context, limit int, lease time.Duration) ([]WebhookDelivery, error)
+	MarkDelivered(ctx context.Context, id int64) error
+	MarkRetry(ctx context.Context, id int64, next time.Time, reason string) error
+	MarkFailed(ctx context.Context, id int64, reason string) error
+}
+
+type OutboxDB interface {
+	TakeDue(ctx context.Context, limit int, lease time.Duration) ([]OutboxMessage, error)
+	MarkDispatched(ctx context.Context, id int64) error
+	MarkRetry(ctx context.Context, id int64, next time.Time, reason string) error
 }
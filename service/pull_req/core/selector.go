@@ -0,0 +1,112 @@
+package core
+
+import (
+	"cmp"
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+const (
+	StrategyRandom      = "RANDOM"
+	StrategyRoundRobin  = "ROUND_ROBIN"
+	StrategyLeastLoaded = "LEAST_LOADED"
+	StrategyWeighted    = "WEIGHTED"
+)
+
+type Candidate struct {
+	ID          string
+	OpenReviews int
+}
+
+// ReviewerSelector picks n of the candidates. last is the reviewer picked
+// previously from the same team or pool, only round robin needs it.
+type ReviewerSelector interface {
+	Select(candidates []Candidate, last string, n int) []string
+}
+
+func NewReviewerSelectors() map[string]ReviewerSelector {
+	return map[string]ReviewerSelector{
+		StrategyRandom:      RandomSelector{},
+		StrategyRoundRobin:  RoundRobinSelector{},
+		StrategyLeastLoaded: LeastLoadedSelector{},
+		StrategyWeighted:    WeightedSelector{},
+	}
+}
+
+func candidateIDs(candidates []Candidate) []string {
+	ids := make([]string, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.ID
+	}
+	return ids
+}
+
+func shuffleCandidates(candidates []Candidate) []Candidate {
+	shuffled := slices.Clone(candidates)
+	rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return shuffled
+}
+
+func firstN(candidates []Candidate, n int) []string {
+	if len(candidates) < n {
+		n = len(candidates)
+	}
+	return candidateIDs(candidates[:n])
+}
+
+type RandomSelector struct{}
+
+func (RandomSelector) Select(candidates []Candidate, _ string, n int) []string {
+	return firstN(shuffleCandidates(candidates), n)
+}
+
+type LeastLoadedSelector struct{}
+
+func (LeastLoadedSelector) Select(candidates []Candidate, _ string, n int) []string {
+	sorted := shuffleCandidates(candidates)
+	slices.SortStableFunc(sorted, func(a, b Candidate) int {
+		return cmp.Compare(a.OpenReviews, b.OpenReviews)
+	})
+	return firstN(sorted, n)
+}
+
+// WeightedSelector picks randomly, but a candidate with k open reviews
+// has weight 1/(k+1), so busy reviewers are chosen less often.
+type WeightedSelector struct{}
+
+func (WeightedSelector) Select(candidates []Candidate, _ string, n int) []string {
+	keys := make(map[string]float64, len(candidates))
+	for _, c := range candidates {
+		keys[c.ID] = math.Pow(rand.Float64(), float64(c.OpenReviews+1))
+	}
+
+	sorted := slices.Clone(candidates)
+	slices.SortFunc(sorted, func(a, b Candidate) int {
+		return cmp.Compare(keys[b.ID], keys[a.ID])
+	})
+	return firstN(sorted, n)
+}
+
+// RoundRobinSelector walks candidates in ID order, continuing after
+// the last reviewer picked. The cursor is kept by the caller.
+type RoundRobinSelector struct{}
+
+func (RoundRobinSelector) Select(candidates []Candidate, last string, n int) []string {
+	sorted := slices.Clone(candidates)
+	slices.SortFunc(sorted, func(a, b Candidate) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	start, _ := slices.BinarySearchFunc(sorted, last, func(c Candidate, id string) int {
+		if c.ID <= id {
+			return -1
+		}
+		return 1
+	})
+	sorted = slices.Concat(sorted[start:], sorted[:start])
+
+	return firstN(sorted, n)
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"slices"
+	"testing"
+)
+
+func testCandidates() []Candidate {
+	return []Candidate{
+		{ID: "u3", OpenReviews: 2},
+		{ID: "u1", OpenReviews: 0},
+		{ID: "u4", OpenReviews: 5},
+		{ID: "u2", OpenReviews: 1},
+	}
+}
+
+func TestSelectorsPickDistinctCandidates(t *testing.T) {
+	for name, selector := range NewReviewerSelectors() {
+		for _, n := range []int{0, 1, 3, 4, 10} {
+			got := selector.Select(testCandidates(), "", n)
+			if want := min(n, 4); len(got) != want {
+				t.Fatalf("%s: n=%d: got %d reviewers, want %d", name, n, len(got), want)
+			}
+			seen := map[string]bool{}
+			for _, id := range got {
+				if seen[id] || !slices.ContainsFunc(testCandidates(), func(c Candidate) bool { return c.ID == id }) {
+					t.Fatalf("%s: n=%d: unexpected or repeated reviewer %q in %v", name, n, id, got)
+				}
+				seen[id] = true
+			}
+		}
+		if got := selector.Select(nil, "", 2); len(got) != 0 {
+			t.Errorf("%s: got %v from no candidates", name, got)
+		}
+	}
+}
+
+func TestLeastLoadedSelector(t *testing.T) {
+	got := LeastLoadedSelector{}.Select(testCandidates(), "", 2)
+	if !slices.Equal(got, []string{"u1", "u2"}) {
+		t.Errorf("got %v, want [u1 u2]", got)
+	}
+}
+
+func TestWeightedSelectorPrefersIdleReviewers(t *testing.T) {
+	candidates := []Candidate{
+		{ID: "idle", OpenReviews: 0},
+		{ID: "busy", OpenReviews: 20},
+	}
+	var selector WeightedSelector
+	idle := 0
+	for range 1000 {
+		if selector.Select(candidates, "", 1)[0] == "idle" {
+			idle++
+		}
+	}
+	// with weights 1 and 1/21 the idle reviewer wins about 95% of the picks
+	if idle < 900 {
+		t.Errorf("idle reviewer picked %d times out of 1000", idle)
+	}
+}
+
+func TestRoundRobinSelector(t *testing.T) {
+	tests := []struct {
+		last string
+		n    int
+		want []string
+	}{
+		{"", 2, []string{"u1", "u2"}},
+		{"u2", 2, []string{"u3", "u4"}},
+		{"u4", 2, []string{"u1", "u2"}},
+		{"u3", 3, []string{"u4", "u1", "u2"}},
+		// the last reviewer may have left the team since
+		{"u25", 1, []string{"u3"}},
+	}
+	for _, tt := range tests {
+		got := RoundRobinSelector{}.Select(testCandidates(), tt.last, tt.n)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("last=%q n=%d: got %v, want %v", tt.last, tt.n, got, tt.want)
+		}
+	}
+}
@@ -1,25 +1,68 @@
 package core
 
 import (
+	"cmp"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
-	"math/rand/v2"
+	"net/url"
 	"slices"
+	"strings"
+	"sync"
+	"time"
 )
 
 type TeamService struct {
-	log *slog.Logger
-	db  TeamDB
+	log          *slog.Logger
+	db           TeamDB
+	tx           Transactor
+	prs          PRPort
+	fallbackTeam string
 }
 
-func NewTeamService(log *slog.Logger, db TeamDB) *TeamService {
+func NewTeamService(log *slog.Logger, db TeamDB, tx Transactor, prs PRPort, fallbackTeam string) *TeamService {
 	return &TeamService{
-		log: log,
-		db:  db,
+		log:          log,
+		db:           db,
+		tx:           tx,
+		prs:          prs,
+		fallbackTeam: fallbackTeam,
+	}
+}
+func validateTeamUpdate(upd TeamUpdate) error {
+	if upd.ReviewerStrategy != nil {
+		if _, ok := NewReviewerSelectors()[*upd.ReviewerStrategy]; !ok {
+			return ErrUnknownStrategy
+		}
+	}
+	if upd.ReviewersRequired != nil && *upd.ReviewersRequired < 1 {
+		return ErrInvalidReviewersCount
+	}
+	if upd.ApprovalsRequired != nil && *upd.ApprovalsRequired < 0 {
+		return ErrInvalidApprovalsCount
 	}
+	return nil
 }
-func (t *TeamService) Create(ctx context.Context, team Team) error {
-	err := t.db.Add(ctx, team)
+func (t *TeamService) Create(ctx context.Context, team Team, moveMembers bool) error {
+	if team.ReviewerStrategy == "" {
+		team.ReviewerStrategy = StrategyLeastLoaded
+	}
+	if team.ReviewersRequired == 0 {
+		team.ReviewersRequired = DefaultReviewersRequired
+	}
+	err := validateTeamUpdate(TeamUpdate{
+		ReviewerStrategy:  &team.ReviewerStrategy,
+		ReviewersRequired: &team.ReviewersRequired,
+	})
+	if err != nil {
+		t.log.Error("invalid team settings", "error", err)
+		return err
+	}
+	err = t.db.Add(ctx, team, moveMembers)
 	if err != nil {
 		t.log.Error("failed to create team", "error", err)
 		return err
@@ -34,47 +77,368 @@ func (t *TeamService) Get(ctx context.Context, name string) (Team, error) {
 	}
 	return team, nil
 }
+func (t *TeamService) SetStrategy(ctx context.Context, name, strategy string) (TeamSettings, error) {
+	return t.Update(ctx, name, TeamUpdate{ReviewerStrategy: &strategy})
+}
+func (t *TeamService) Update(ctx context.Context, name string, upd TeamUpdate) (TeamSettings, error) {
+	if err := validateTeamUpdate(upd); err != nil {
+		t.log.Error("invalid team settings", "error", err)
+		return TeamSettings{}, err
+	}
+	settings, err := t.db.UpdateSettings(ctx, name, upd)
+	if err != nil {
+		t.log.Error("failed to update team", "error", err)
+		return TeamSettings{}, err
+	}
+	return settings, nil
+}
+func (t *TeamService) DeactivateUsers(ctx context.Context, name string, userIDs []string, all bool) (TeamHandoffReport, error) {
+	report := TeamHandoffReport{TeamName: name}
+	err := t.tx.WithinTx(ctx, func(ctx context.Context) error {
+		team, err := t.db.Get(ctx, name)
+		if err != nil {
+			t.log.Error("failed to get team", "error", err)
+			return err
+		}
+
+		memberIDs := make([]string, len(team.Members))
+		for i, m := range team.Members {
+			memberIDs[i] = m.ID
+		}
+		ids := slices.Clone(userIDs)
+		if all {
+			ids = memberIDs
+		}
+		slices.Sort(ids)
+		ids = slices.Compact(ids)
+		for _, id := range ids {
+			if !slices.Contains(memberIDs, id) {
+				t.log.Error("user is not a team member", "user", id, "team", name)
+				return ErrNotMember
+			}
+		}
+
+		// everyone is deactivated before handoff so that
+		// deactivated users are never picked as replacements
+		if err = t.db.DeactivateMembers(ctx, name, ids); err != nil {
+			t.log.Error("failed to deactivate users", "error", err)
+			return err
+		}
+		for _, id := range ids {
+			handoff, err := t.prs.HandOff(ctx, id, t.fallbackTeam)
+			if err != nil {
+				t.log.Error("failed to reassign reviews", "user", id, "error", err)
+				return err
+			}
+			report.Users = append(report.Users, UserHandoff{
+				UserID:        id,
+				HandoffReport: handoff,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return TeamHandoffReport{}, err
+	}
+	return report, nil
+}
+func (t *TeamService) AddMember(ctx context.Context, name string, member TeamMember) (Team, error) {
+	var team Team
+	err := t.tx.WithinTx(ctx, func(ctx context.Context) error {
+		err := t.db.AddMember(ctx, name, member)
+		if err != nil {
+			t.log.Error("failed to add member", "error", err)
+			return err
+		}
+		team, err = t.db.Get(ctx, name)
+		if err != nil {
+			t.log.Error("failed to get team", "error", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return Team{}, err
+	}
+	return team, nil
+}
+func (t *TeamService) RemoveMember(ctx context.Context, name, userID string) (TeamHandoffReport, error) {
+	report := TeamHandoffReport{TeamName: name}
+	err := t.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// reviews are handed off while the user is still a member,
+		// so replacements come from the same team
+		handoff, err := t.prs.HandOff(ctx, userID, t.fallbackTeam)
+		if err != nil {
+			t.log.Error("failed to reassign reviews", "user", userID, "error", err)
+			return err
+		}
+		if err = t.db.RemoveMember(ctx, name, userID); err != nil {
+			t.log.Error("failed to remove member", "error", err)
+			return err
+		}
+		report.Users = []UserHandoff{{UserID: userID, HandoffReport: handoff}}
+		return nil
+	})
+	if err != nil {
+		return TeamHandoffReport{}, err
+	}
+	return report, nil
+}
+func (t *TeamService) Rename(ctx context.Context, name, newName string) (TeamSettings, error) {
+	settings, err := t.db.Rename(ctx, name, newName)
+	if err != nil {
+		t.log.Error("failed to rename team", "error", err)
+		return TeamSettings{}, err
+	}
+	return settings, nil
+}
+func (t *TeamService) Delete(ctx context.Context, name string) (TeamHandoffReport, error) {
+	report := TeamHandoffReport{TeamName: name}
+	err := t.tx.WithinTx(ctx, func(ctx context.Context) error {
+		team, err := t.db.Get(ctx, name)
+		if err != nil {
+			t.log.Error("failed to get team", "error", err)
+			return err
+		}
+		// as in RemoveMember, reviews are handed off while the team still
+		// exists, so replacements can come from it
+		for _, m := range team.Members {
+			handoff, err := t.prs.HandOff(ctx, m.ID, t.fallbackTeam)
+			if err != nil {
+				t.log.Error("failed to reassign reviews", "user", m.ID, "error", err)
+				return err
+			}
+			report.Users = append(report.Users, UserHandoff{
+				UserID:        m.ID,
+				HandoffReport: handoff,
+			})
+		}
+		if err = t.db.Delete(ctx, name); err != nil {
+			t.log.Error("failed to delete team", "error", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return TeamHandoffReport{}, err
+	}
+	return report, nil
+}
+func (t *TeamService) SetReviewRule(ctx context.Context, rule ReviewRule) ([]ReviewRule, error) {
+	if rule.Reviewers < 1 || rule.TeamName == rule.RequiredTeam {
+		t.log.Error("invalid review rule", "team", rule.TeamName, "required_team", rule.RequiredTeam)
+		return nil, ErrInvalidReviewRule
+	}
+	if err := t.db.SetReviewRule(ctx, rule); err != nil {
+		t.log.Error("failed to set review rule", "error", err)
+		return nil, err
+	}
+	return t.ReviewRules(ctx, rule.TeamName)
+}
+func (t *TeamService) RemoveReviewRule(ctx context.Context, name, requiredTeam string) ([]ReviewRule, error) {
+	if err := t.db.DeleteReviewRule(ctx, name, requiredTeam); err != nil {
+		t.log.Error("failed to remove review rule", "error", err)
+		return nil, err
+	}
+	return t.ReviewRules(ctx, name)
+}
+func (t *TeamService) ReviewRules(ctx context.Context, name string) ([]ReviewRule, error) {
+	rules, err := t.db.GetReviewRules(ctx, name)
+	if err != nil {
+		t.log.Error("failed to get review rules", "error", err)
+		return nil, err
+	}
+	return rules, nil
+}
 
 type UserService struct {
 	log *slog.Logger
 	db  UserDB
+	tx  Transactor
+	prs PRPort
 }
 
-func NewUserService(log *slog.Logger, db UserDB) *UserService {
+func NewUserService(log *slog.Logger, db UserDB, tx Transactor, prs PRPort) *UserService {
 	return &UserService{
 		log: log,
 		db:  db,
+		tx:  tx,
+		prs: prs,
 	}
 }
-func (u *UserService) SetFlag(ctx context.Context, id string, isActive bool) (User, error) {
-	user, err := u.db.UpdateIsActive(ctx, id, isActive)
+func (u *UserService) Get(ctx context.Context, id string) (User, error) {
+	user, err := u.db.Get(ctx, id)
 	if err != nil {
-		u.log.Error("failed to set flag", "error", err)
+		u.log.Error("failed to get user", "error", err)
 		return User{}, err
 	}
 	return user, nil
 }
-
-type PRService struct {
-	log *slog.Logger
-	db  PRDB
+func (u *UserService) List(ctx context.Context, filter UserFilter) ([]User, error) {
+	users, err := u.db.List(ctx, filter)
+	if err != nil {
+		u.log.Error("failed to list users", "error", err)
+		return nil, err
+	}
+	return users, nil
 }
-
-func getRandomStrings(src []string, n int) []string {
-	if len(src) == 0 {
+func (u *UserService) Rename(ctx context.Context, id, name string) (User, error) {
+	user, err := u.db.UpdateName(ctx, id, name)
+	if err != nil {
+		u.log.Error("failed to rename user", "error", err)
+		return User{}, err
+	}
+	return user, nil
+}
+func (u *UserService) Delete(ctx context.Context, id string) (HandoffReport, error) {
+	var report HandoffReport
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		report, err = u.prs.HandOff(ctx, id, "")
+		if err != nil {
+			u.log.Error("failed to reassign reviews", "error", err)
+			return err
+		}
+		if err = u.db.Delete(ctx, id); err != nil {
+			u.log.Error("failed to delete user", "error", err)
+			return err
+		}
 		return nil
+	})
+	if err != nil {
+		return HandoffReport{}, err
 	}
+	return report, nil
+}
+func (u *UserService) SetFlag(ctx context.Context, id string, isActive, reassignReviews bool) (User, *HandoffReport, error) {
+	var user User
+	var report *HandoffReport
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = u.db.UpdateIsActive(ctx, id, isActive)
+		if err != nil {
+			u.log.Error("failed to set flag", "error", err)
+			return err
+		}
+		if isActive || !reassignReviews {
+			return nil
+		}
 
-	rand.Shuffle(len(src), func(i, j int) {
-		src[i], src[j] = src[j], src[i]
+		handoff, err := u.prs.HandOff(ctx, id, "")
+		if err != nil {
+			u.log.Error("failed to reassign reviews", "error", err)
+			return err
+		}
+		report = &handoff
+		return nil
 	})
+	if err != nil {
+		return User{}, nil, err
+	}
+	return user, report, nil
+}
+func (u *UserService) AddAbsence(ctx context.Context, absence Absence) (Absence, error) {
+	if !absence.To.After(absence.From) {
+		u.log.Error("invalid absence period", "from", absence.From, "to", absence.To)
+		return Absence{}, ErrInvalidPeriod
+	}
+	absence, err := u.db.AddAbsence(ctx, absence)
+	if err != nil {
+		u.log.Error("failed to add absence", "error", err)
+		return Absence{}, err
+	}
+	return absence, nil
+}
+func (u *UserService) ListAbsences(ctx context.Context, userID string) ([]Absence, error) {
+	// an unknown user is not the same as a user without absences
+	if _, err := u.db.Get(ctx, userID); err != nil {
+		u.log.Error("failed to get user", "error", err)
+		return nil, err
+	}
+	absences, err := u.db.GetAbsences(ctx, userID)
+	if err != nil {
+		u.log.Error("failed to get absences", "error", err)
+		return nil, err
+	}
+	return absences, nil
+}
+func (u *UserService) RemoveAbsence(ctx context.Context, id int64) (Absence, error) {
+	absence, err := u.db.DeleteAbsence(ctx, id)
+	if err != nil {
+		u.log.Error("failed to remove absence", "error", err)
+		return Absence{}, err
+	}
+	return absence, nil
+}
+func (u *UserService) MoveTeam(ctx context.Context, id, teamName, policy string) (User, *HandoffReport, error) {
+	if policy == "" {
+		policy = MoveKeepReviews
+	}
+	if policy != MoveKeepReviews && policy != MoveReassignOld && policy != MoveReassignNew {
+		u.log.Error("unknown move policy", "policy", policy)
+		return User{}, nil, ErrUnknownMovePolicy
+	}
+
+	var user User
+	var report *HandoffReport
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if policy == MoveReassignOld {
+			handoff, err := u.prs.HandOff(ctx, id, "")
+			if err != nil {
+				u.log.Error("failed to reassign reviews", "error", err)
+				return err
+			}
+			report = &handoff
+		}
 
-	if len(src) < n {
-		n = len(src)
+		var err error
+		user, err = u.db.UpdateTeam(ctx, id, teamName)
+		if err != nil {
+			u.log.Error("failed to move user", "error", err)
+			return err
+		}
+
+		if policy == MoveReassignNew {
+			handoff, err := u.prs.HandOff(ctx, id, "")
+			if err != nil {
+				u.log.Error("failed to reassign reviews", "error", err)
+				return err
+			}
+			report = &handoff
+		}
+		return nil
+	})
+	if err != nil {
+		return User{}, nil, err
 	}
+	return user, report, nil
+}
+func (u *UserService) HandOffAbsentReviews(ctx context.Context) error {
+	return u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		absences, err := u.db.TakeStartedHandoffs(ctx)
+		if err != nil {
+			u.log.Error("failed to get started absences", "error", err)
+			return err
+		}
+		for _, absence := range absences {
+			report, err := u.prs.HandOff(ctx, absence.UserID, "")
+			if err != nil {
+				return err
+			}
+			u.log.Info("reviews handed off", "user", absence.UserID,
+				"reassigned", len(report.Reassigned), "no_candidate", report.NoCandidate)
+		}
+		return nil
+	})
+}
 
-	return src[:n]
+type PRService struct {
+	log       *slog.Logger
+	db        PRDB
+	tx        Transactor
+	selectors map[string]ReviewerSelector
 }
+
 func removeByValue(src []string, val string) []string {
 	result := src[:0]
 	for _, v := range src {
@@ -85,89 +449,1266 @@ func removeByValue(src []string, val string) []string {
 
 	return result
 }
-func NewPRService(log *slog.Logger, db PRDB) *PRService {
+func NewPRService(log *slog.Logger, db PRDB, tx Transactor) *PRService {
 	return &PRService{
-		log: log,
-		db:  db,
+		log:       log,
+		db:        db,
+		tx:        tx,
+		selectors: NewReviewerSelectors(),
 	}
 }
-func (pr *PRService) Create(ctx context.Context, prID, name, authorID string) (PullRequest, error) {
-	teamMembersIDs, err := pr.db.GetActiveTeamMemberIDsByUserID(ctx, authorID)
+
+// selectReviewers picks n of ids with the team's strategy. poolRepo is set
+// when the candidates come from that repository's reviewer pool, which then
+// owns the round robin cursor instead of the team.
+func (pr *PRService) selectReviewers(ctx context.Context, settings TeamSettings, poolRepo string, ids []string, n int) ([]string, error) {
+	selector, ok := pr.selectors[settings.ReviewerStrategy]
+	if !ok {
+		selector = pr.selectors[StrategyLeastLoaded]
+	}
+
+	load, err := pr.db.GetOpenReviewCounts(ctx, ids)
 	if err != nil {
-		pr.log.Error("failed to get reviewers", "error", err)
-		return PullRequest{}, err
+		pr.log.Error("failed to get review load", "error", err)
+		return nil, err
+	}
+	candidates := make([]Candidate, len(ids))
+	for i, id := range ids {
+		candidates[i] = Candidate{
+			ID:          id,
+			OpenReviews: load[id],
+		}
 	}
 
-	teamMembersIDs = removeByValue(teamMembersIDs, authorID)
-	reviewers := getRandomStrings(teamMembersIDs, 2)
+	if settings.ReviewerStrategy != StrategyRoundRobin {
+		return selector.Select(candidates, "", n), nil
+	}
 
-	err = pr.db.Add(ctx, prID, name, authorID, reviewers)
+	// the cursor is stored so that it survives restarts and is shared by replicas
+	last, err := pr.db.GetRoundRobinLast(ctx, settings.Name, poolRepo)
 	if err != nil {
-		pr.log.Error("failed to create pr", "error", err)
-		return PullRequest{}, err
+		pr.log.Error("failed to get round robin cursor", "team", settings.Name, "repository", poolRepo, "error", err)
+		return nil, err
 	}
-	return PullRequest{
-		PullRequestShort: PullRequestShort{
-			ID:       prID,
-			Name:     name,
-			AuthorID: authorID,
-			Status:   "OPEN",
-		},
-		Reviewers: reviewers,
-	}, nil
+	picked := selector.Select(candidates, last, n)
+	if len(picked) > 0 {
+		err = pr.db.SetRoundRobinLast(ctx, settings.Name, poolRepo, picked[len(picked)-1])
+		if err != nil {
+			pr.log.Error("failed to save round robin cursor", "team", settings.Name, "repository", poolRepo, "error", err)
+			return nil, err
+		}
+	}
+	return picked, nil
 }
-func (pr *PRService) Merge(ctx context.Context, id string) (PullRequest, error) {
-	pullReq, err := pr.db.UpdateMerged(ctx, id)
+
+// assignReviewers returns the PR's reviewers and the rule teams of those
+// assigned by the author team's review rules.
+func (pr *PRService) assignReviewers(ctx context.Context, pullReq PullRequest) ([]string, map[string]string, error) {
+	// an author who has left their team still gets reviewers from the
+	// repository pool and the code owners
+	settings, err := pr.db.GetTeamSettingsByUserID(ctx, pullReq.AuthorID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		pr.log.Error("failed to get team settings", "error", err)
+		return nil, nil, err
+	}
+	repo, pool, err := pr.repositoryPool(ctx, pullReq.Repository)
 	if err != nil {
-		pr.log.Error("failed to merge pr", "error", err)
-		return PullRequest{}, err
+		return nil, nil, err
 	}
-	return pullReq, nil
-}
-func (pr *PRService) Reassign(ctx context.Context, prID, oldReviewerID string) (PullRequest, string, error) {
-	currentPR, err := pr.db.Get(ctx, prID)
+	count := cmp.Or(pullReq.ReviewersCount, repo.ReviewersRequired, settings.ReviewersRequired)
+
+	ruleReviewers, ruleTeams, err := pr.ruleReviewers(ctx, settings.Name, pullReq.AuthorID)
 	if err != nil {
-		pr.log.Error("failed to get pr", "error", err)
-		return PullRequest{}, "", err
+		return nil, nil, err
 	}
-	if currentPR.Status == "MERGED" {
-		pr.log.Error("pr already merged", "error", err)
-		return PullRequest{}, "", ErrAlredyMerged
+
+	var teamMembersIDs []string
+	var poolRepo string
+	if len(repo.ReviewerPool) > 0 {
+		poolRepo = repo.Name
+		teamMembersIDs = pool
+	} else if settings.Name != "" {
+		teamMembersIDs, err = pr.db.GetActiveTeamMemberIDs(ctx, settings.Name)
+		if err != nil {
+			pr.log.Error("failed to get reviewers", "error", err)
+			return nil, nil, err
+		}
 	}
-	isAssigned := slices.Contains(currentPR.Reviewers, oldReviewerID)
-	if !isAssigned {
-		return PullRequest{}, "", ErrNotAssigned
+	teamMembersIDs = removeByValue(teamMembersIDs, pullReq.AuthorID)
+	for _, id := range ruleReviewers {
+		teamMembersIDs = removeByValue(teamMembersIDs, id)
 	}
 
-	teamMembersIDs, err := pr.db.GetActiveTeamMemberIDsByUserID(ctx, oldReviewerID)
+	owners, err := pr.codeowners(ctx, pullReq)
 	if err != nil {
-		pr.log.Error("failed to get reviewers", "error", err)
-		return PullRequest{}, "", err
+		return nil, nil, err
+	}
+	var reviewers []string
+	// one slot goes to an owner of the touched paths unless a rule reviewer
+	// already owns them, the rest to the team
+	if count > 0 && len(owners) > 0 && !slices.ContainsFunc(ruleReviewers, func(id string) bool {
+		return slices.Contains(owners, id)
+	}) {
+		reviewers, err = pr.selectReviewers(ctx, settings, "", owners, 1)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, id := range reviewers {
+			teamMembersIDs = removeByValue(teamMembersIDs, id)
+		}
+	}
+	rest, err := pr.selectReviewers(ctx, settings, poolRepo, teamMembersIDs, count-len(reviewers))
+	if err != nil {
+		return nil, nil, err
 	}
+	return slices.Concat(ruleReviewers, reviewers, rest), ruleTeams, nil
+}
 
-	for _, revID := range currentPR.Reviewers {
-		teamMembersIDs = removeByValue(teamMembersIDs, revID)
+// ruleReviewers picks reviewers for every review rule of the author's team,
+// each rule from its required team with that team's strategy.
+func (pr *PRService) ruleReviewers(ctx context.Context, teamName, authorID string) ([]string, map[string]string, error) {
+	rules, err := pr.db.GetReviewRules(ctx, teamName)
+	if err != nil {
+		pr.log.Error("failed to get review rules", "team", teamName, "error", err)
+		return nil, nil, err
 	}
-	teamMembersIDs = removeByValue(teamMembersIDs, currentPR.AuthorID)
 
-	if len(teamMembersIDs) < 1 {
-		pr.log.Error("there is no candidates", "error", ErrNoCandidate)
-		return PullRequest{}, "", ErrNoCandidate
+	var reviewers []string
+	ruleTeams := map[string]string{}
+	for _, rule := range rules {
+		settings, err := pr.db.GetTeamSettings(ctx, rule.RequiredTeam)
+		if err != nil {
+			pr.log.Error("failed to get team settings", "team", rule.RequiredTeam, "error", err)
+			return nil, nil, err
+		}
+		candidates, err := pr.db.GetActiveTeamMemberIDs(ctx, rule.RequiredTeam)
+		if err != nil {
+			pr.log.Error("failed to get reviewers", "team", rule.RequiredTeam, "error", err)
+			return nil, nil, err
+		}
+		candidates = removeByValue(candidates, authorID)
+		for _, id := range reviewers {
+			candidates = removeByValue(candidates, id)
+		}
+
+		picked, err := pr.selectReviewers(ctx, settings, "", candidates, rule.Reviewers)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(picked) < rule.Reviewers {
+			pr.log.Error("not enough reviewers for review rule", "team", teamName, "required_team", rule.RequiredTeam, "picked", len(picked))
+			return nil, nil, fmt.Errorf("%w: %s", ErrRuleUnsatisfied, rule.RequiredTeam)
+		}
+		for _, id := range picked {
+			ruleTeams[id] = rule.RequiredTeam
+		}
+		reviewers = append(reviewers, picked...)
 	}
-	newReviewerID := getRandomStrings(teamMembersIDs, 1)[0]
+	return reviewers, ruleTeams, nil
+}
 
-	pullReq, err := pr.db.UpdateReviewer(ctx, prID, oldReviewerID, newReviewerID)
+// repositoryPool returns the PR's repository settings together with the active
+// part of its reviewer pool. PRs without a repository get zero settings.
+func (pr *PRService) repositoryPool(ctx context.Context, name string) (Repository, []string, error) {
+	if name == "" {
+		return Repository{}, nil, nil
+	}
+	repo, err := pr.db.GetRepository(ctx, name)
 	if err != nil {
-		pr.log.Error("failed to reassign pr", "error", err)
-		return PullRequest{}, "", err
+		pr.log.Error("failed to get repository", "repository", name, "error", err)
+		return Repository{}, nil, err
 	}
-	return pullReq, newReviewerID, nil
+	if len(repo.ReviewerPool) == 0 {
+		return repo, nil, nil
+	}
+	pool, err := pr.db.GetActiveUserIDs(ctx, repo.ReviewerPool)
+	if err != nil {
+		pr.log.Error("failed to get reviewer pool", "repository", name, "error", err)
+		return Repository{}, nil, err
+	}
+	return repo, pool, nil
 }
-func (pr *PRService) ListByReviewer(ctx context.Context, reviewerID string) ([]PullRequestShort, error) {
-	pullReqs, err := pr.db.GetByReviewer(ctx, reviewerID)
+
+// codeowners returns the active users owning any of the PR's changed files.
+// Owners are our user ids ("@u1") or team names ("@org/backend"); emails are
+// skipped since we have nothing to map them to.
+func (pr *PRService) codeowners(ctx context.Context, pullReq PullRequest) ([]string, error) {
+	if pullReq.Repository == "" || len(pullReq.ChangedFiles) == 0 {
+		return nil, nil
+	}
+	content, err := pr.db.GetCodeowners(ctx, pullReq.Repository)
 	if err != nil {
-		pr.log.Error("failed to get list by reviewer", "error", err)
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		pr.log.Error("failed to get codeowners", "error", err)
+		return nil, err
+	}
+	co, err := ParseCodeowners(content)
+	if err != nil {
+		pr.log.Error("stored codeowners are invalid", "repository", pullReq.Repository, "error", err)
+		return nil, err
+	}
+
+	var userIDs []string
+	teams := map[string]bool{}
+	for _, file := range pullReq.ChangedFiles {
+		for _, owner := range co.Owners(file) {
+			name, ok := strings.CutPrefix(owner, "@")
+			if !ok {
+				continue
+			}
+			if _, team, isTeam := strings.Cut(name, "/"); isTeam {
+				teams[team] = true
+			} else {
+				userIDs = append(userIDs, name)
+			}
+		}
+	}
+	for team := range teams {
+		members, err := pr.db.GetActiveTeamMemberIDs(ctx, team)
+		if err != nil {
+			pr.log.Error("failed to get owner team members", "team", team, "error", err)
+			return nil, err
+		}
+		userIDs = append(userIDs, members...)
+	}
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	owners, err := pr.db.GetActiveUserIDs(ctx, userIDs)
+	if err != nil {
+		pr.log.Error("failed to get active owners", "error", err)
 		return nil, err
 	}
-	return pullReqs, nil
+	return removeByValue(owners, pullReq.AuthorID), nil
+}
+func (pr *PRService) addEvents(ctx context.Context, events ...PREvent) error {
+	if err := pr.db.AddEvents(ctx, events); err != nil {
+		pr.log.Error("failed to add pr events", "error", err)
+		return err
+	}
+	return nil
+}
+func assignedEvents(prID string, reviewers []string) []PREvent {
+	events := make([]PREvent, len(reviewers))
+	for i, id := range reviewers {
+		events[i] = PREvent{PRID: prID, Type: EventAssigned, NewUserID: id}
+	}
+	return events
+}
+func (pr *PRService) Create(ctx context.Context, newPR NewPullRequest) (PullRequest, error) {
+	if newPR.ReviewersCount < 0 {
+		pr.log.Error("invalid reviewers count", "count", newPR.ReviewersCount)
+		return PullRequest{}, ErrInvalidReviewersCount
+	}
+
+	var pullReq PullRequest
+	err := pr.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		pullReq, err = pr.create(ctx, newPR)
+		return err
+	})
+	if err != nil {
+		return PullRequest{}, err
+	}
+	return pullReq, nil
+}
+func (pr *PRService) create(ctx context.Context, newPR NewPullRequest) (PullRequest, error) {
+	settings, err := pr.db.GetTeamSettingsByUserID(ctx, newPR.AuthorID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		pr.log.Error("failed to get team settings", "error", err)
+		return PullRequest{}, err
+	}
+	pullReq := PullRequest{
+		PullRequestShort: PullRequestShort{
+			ID:       newPR.ID,
+			Name:     newPR.Name,
+			AuthorID: newPR.AuthorID,
+			Status:   StatusOpen,
+		},
+		ReviewersCount:    newPR.ReviewersCount,
+		ApprovalsRequired: settings.ApprovalsRequired,
+		Repository:        newPR.Repository,
+		ChangedFiles:      newPR.ChangedFiles,
+	}
+	if newPR.Draft {
+		pullReq.Status = StatusDraft
+	} else {
+		reviewers, ruleTeams, err := pr.assignReviewers(ctx, pullReq)
+		if err != nil {
+			return PullRequest{}, err
+		}
+		pullReq.Reviewers = reviewers
+		pullReq.RuleTeams = ruleTeams
+	}
+
+	err = pr.db.Add(ctx, pullReq)
+	if err != nil {
+		pr.log.Error("failed to create pr", "error", err)
+		return PullRequest{}, err
+	}
+	created := PREvent{
+		PRID:      pullReq.ID,
+		Type:      EventCreated,
+		ActorID:   pullReq.AuthorID,
+		NewStatus: pullReq.Status,
+	}
+	err = pr.addEvents(ctx, append([]PREvent{created}, assignedEvents(pullReq.ID, pullReq.Reviewers)...)...)
+	if err != nil {
+		return PullRequest{}, err
+	}
+
+	pullReq.Reviews = make([]Review, len(pullReq.Reviewers))
+	for i, id := range pullReq.Reviewers {
+		pullReq.Reviews[i] = Review{
+			ReviewerID: id,
+			State:      ReviewPending,
+		}
+	}
+	return pullReq, nil
+}
+func (pr *PRService) Merge(ctx context.Context, id string) (PullRequest, error) {
+	var pullReq PullRequest
+	err := pr.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		pullReq, err = pr.merge(ctx, id)
+		return err
+	})
+	if err != nil {
+		return PullRequest{}, err
+	}
+	return pullReq, nil
+}
+func (pr *PRService) merge(ctx context.Context, id string) (PullRequest, error) {
+	currentPR, err := pr.db.GetForUpdate(ctx, id)
+	if err != nil {
+		pr.log.Error("failed to get pr", "error", err)
+		return PullRequest{}, err
+	}
+	switch currentPR.Status {
+	case StatusMerged:
+		return currentPR, nil
+	case StatusClosed:
+		pr.log.Error("pr closed", "pr", id)
+		return PullRequest{}, ErrPRClosed
+	case StatusDraft:
+		pr.log.Error("pr is a draft", "pr", id)
+		return PullRequest{}, ErrPRDraft
+	}
+
+	approvals := 0
+	ruleReviewers := map[string]int{}
+	ruleApprovals := map[string]int{}
+	for _, review := range currentPR.Reviews {
+		if review.RuleTeam != "" {
+			ruleReviewers[review.RuleTeam]++
+		}
+		switch review.State {
+		case ReviewApproved:
+			approvals++
+			if review.RuleTeam != "" {
+				ruleApprovals[review.RuleTeam]++
+			}
+		case ReviewChangesRequested:
+			pr.log.Error("changes requested", "pr", id, "reviewer", review.ReviewerID)
+			return PullRequest{}, ErrChangesRequested
+		}
+	}
+	// a rule gets exactly rule.Reviewers reviewers at creation and reassign
+	// keeps the rule team, so each rule needs all of its reviewers' approvals
+	for team, reviewers := range ruleReviewers {
+		if ruleApprovals[team] < reviewers {
+			pr.log.Error("review rule not approved", "pr", id, "required_team", team, "approvals", ruleApprovals[team])
+			return PullRequest{}, ErrNotApproved
+		}
+	}
+	// a PR can not collect more approvals than it has reviewers, but a PR
+	// without reviewers does not skip the requirement either
+	if approvals < min(currentPR.ApprovalsRequired, max(len(currentPR.Reviewers), 1)) {
+		pr.log.Error("not enough approvals", "pr", id, "approvals", approvals)
+		return PullRequest{}, ErrNotApproved
+	}
+
+	return pr.markMerged(ctx, currentPR)
+}
+
+// RecordMerge records a merge that has already happened on the forge, so
+// the approval and state checks of Merge do not apply.
+func (pr *PRService) RecordMerge(ctx context.Context, id string) (PullRequest, error) {
+	var pullReq PullRequest
+	err := pr.tx.WithinTx(ctx, func(ctx context.Context) error {
+		currentPR, err := pr.db.GetForUpdate(ctx, id)
+		if err != nil {
+			pr.log.Error("failed to get pr", "error", err)
+			return err
+		}
+		if currentPR.Status == StatusMerged {
+			pullReq = currentPR
+			return nil
+		}
+		pullReq, err = pr.markMerged(ctx, currentPR)
+		return err
+	})
+	if err != nil {
+		return PullRequest{}, err
+	}
+	return pullReq, nil
+}
+func (pr *PRService) markMerged(ctx context.Context, currentPR PullRequest) (PullRequest, error) {
+	pullReq, err := pr.db.UpdateMerged(ctx, currentPR.ID)
+	if err != nil {
+		pr.log.Error("failed to merge pr", "error", err)
+		return PullRequest{}, err
+	}
+	err = pr.addEvents(ctx, PREvent{
+		PRID:      currentPR.ID,
+		Type:      EventMerged,
+		OldStatus: currentPR.Status,
+		NewStatus: StatusMerged,
+	})
+	if err != nil {
+		return PullRequest{}, err
+	}
+	return pullReq, nil
+}
+func checkOpen(status string) error {
+	switch status {
+	case StatusMerged:
+		return ErrAlredyMerged
+	case StatusClosed:
+		return ErrPRClosed
+	case StatusDraft:
+		return ErrPRDraft
+	}
+	return nil
+}
+func (pr *PRService) Reassign(ctx context.Context, prID, oldReviewerID, actorID, reason string) (PullRequest, string, error) {
+	var pullReq PullRequest
+	var newReviewerID string
+	err := pr.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		pullReq, newReviewerID, err = pr.reassign(ctx, prID, oldReviewerID, "", actorID, reason)
+		return err
+	})
+	if err != nil {
+		return PullRequest{}, "", err
+	}
+	return pullReq, newReviewerID, nil
+}
+func (pr *PRService) replacementCandidates(ctx context.Context, teamName string, currentPR PullRequest) ([]string, error) {
+	teamMembersIDs, err := pr.db.GetActiveTeamMemberIDs(ctx, teamName)
+	if err != nil {
+		pr.log.Error("failed to get reviewers", "error", err)
+		return nil, err
+	}
+
+	return withoutParticipants(teamMembersIDs, currentPR), nil
+}
+func withoutParticipants(ids []string, currentPR PullRequest) []string {
+	ids = slices.Clone(ids)
+	for _, revID := range currentPR.Reviewers {
+		ids = removeByValue(ids, revID)
+	}
+	return removeByValue(ids, currentPR.AuthorID)
+}
+
+// isOwnerPick reports whether reviewerID is the only codeowner among the
+// reviewers, i.e. the one holding the owner slot.
+func isOwnerPick(owners, reviewers []string, reviewerID string) bool {
+	if !slices.Contains(owners, reviewerID) {
+		return false
+	}
+	for _, id := range reviewers {
+		if id != reviewerID && slices.Contains(owners, id) {
+			return false
+		}
+	}
+	return true
+}
+func (pr *PRService) reassign(ctx context.Context, prID, oldReviewerID, fallbackTeam, actorID, reason string) (PullRequest, string, error) {
+	currentPR, err := pr.db.GetForUpdate(ctx, prID)
+	if err != nil {
+		pr.log.Error("failed to get pr", "error", err)
+		return PullRequest{}, "", err
+	}
+	if err = checkOpen(currentPR.Status); err != nil {
+		pr.log.Error("pr is not open", "pr", prID, "error", err)
+		return PullRequest{}, "", err
+	}
+	isAssigned := slices.Contains(currentPR.Reviewers, oldReviewerID)
+	if !isAssigned {
+		return PullRequest{}, "", ErrNotAssigned
+	}
+
+	repo, pool, err := pr.repositoryPool(ctx, currentPR.Repository)
+	if err != nil {
+		return PullRequest{}, "", err
+	}
+
+	var ruleTeam string
+	for _, review := range currentPR.Reviews {
+		if review.ReviewerID == oldReviewerID {
+			ruleTeam = review.RuleTeam
+		}
+	}
+
+	var teamMembersIDs []string
+	var poolRepo string
+	var settings TeamSettings
+	if ruleTeam != "" {
+		// the replacement has to satisfy the same review rule
+		settings, err = pr.db.GetTeamSettings(ctx, ruleTeam)
+	} else {
+		settings, err = pr.db.GetTeamSettingsByUserID(ctx, oldReviewerID)
+	}
+	switch {
+	case ruleTeam != "" && err == nil:
+		teamMembersIDs, err = pr.replacementCandidates(ctx, ruleTeam, currentPR)
+		if err != nil {
+			return PullRequest{}, "", err
+		}
+	case len(repo.ReviewerPool) > 0 && (err == nil || errors.Is(err, ErrNotFound)):
+		poolRepo = repo.Name
+		teamMembersIDs = withoutParticipants(pool, currentPR)
+	case errors.Is(err, ErrNotFound):
+		// the reviewer is not in any team, only the fallback team is left
+	case err != nil:
+		pr.log.Error("failed to get team settings", "error", err)
+		return PullRequest{}, "", err
+	default:
+		teamMembersIDs, err = pr.replacementCandidates(ctx, settings.Name, currentPR)
+		if err != nil {
+			return PullRequest{}, "", err
+		}
+	}
+
+	if ruleTeam == "" {
+		owners, err := pr.codeowners(ctx, currentPR)
+		if err != nil {
+			return PullRequest{}, "", err
+		}
+		// the owner slot stays with an owner when one is left to take it
+		if isOwnerPick(owners, currentPR.Reviewers, oldReviewerID) {
+			if candidates := withoutParticipants(owners, currentPR); len(candidates) > 0 {
+				poolRepo = ""
+				teamMembersIDs = candidates
+			}
+		}
+	}
+
+	if len(teamMembersIDs) < 1 && ruleTeam == "" && fallbackTeam != "" && (poolRepo != "" || fallbackTeam != settings.Name) {
+		poolRepo = ""
+		settings, err = pr.db.GetTeamSettings(ctx, fallbackTeam)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			// a misconfigured fallback team only means there is no candidate
+			pr.log.Error("fallback team not found", "team", fallbackTeam)
+		case err != nil:
+			pr.log.Error("failed to get fallback team settings", "error", err)
+			return PullRequest{}, "", err
+		default:
+			teamMembersIDs, err = pr.replacementCandidates(ctx, fallbackTeam, currentPR)
+			if err != nil {
+				return PullRequest{}, "", err
+			}
+		}
+	}
+
+	if len(teamMembersIDs) < 1 {
+		pr.log.Error("there is no candidates", "error", ErrNoCandidate)
+		return PullRequest{}, "", ErrNoCandidate
+	}
+	newReviewers, err := pr.selectReviewers(ctx, settings, poolRepo, teamMembersIDs, 1)
+	if err != nil {
+		return PullRequest{}, "", err
+	}
+	newReviewerID := newReviewers[0]
+
+	pullReq, err := pr.db.UpdateReviewer(ctx, prID, oldReviewerID, newReviewerID)
+	if err != nil {
+		pr.log.Error("failed to reassign pr", "error", err)
+		return PullRequest{}, "", err
+	}
+	err = pr.addEvents(ctx, PREvent{
+		PRID:      prID,
+		Type:      EventReassigned,
+		ActorID:   actorID,
+		OldUserID: oldReviewerID,
+		NewUserID: newReviewerID,
+		Reason:    reason,
+	})
+	if err != nil {
+		return PullRequest{}, "", err
+	}
+	return pullReq, newReviewerID, nil
+}
+func (pr *PRService) Review(ctx context.Context, prID, reviewerID, state string) (PullRequest, error) {
+	if !slices.Contains([]string{ReviewApproved, ReviewChangesRequested, ReviewCommented}, state) {
+		pr.log.Error("invalid review state", "state", state)
+		return PullRequest{}, ErrInvalidReviewState
+	}
+
+	var pullReq PullRequest
+	err := pr.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		pullReq, err = pr.review(ctx, prID, reviewerID, state)
+		return err
+	})
+	if err != nil {
+		return PullRequest{}, err
+	}
+	return pullReq, nil
+}
+func (pr *PRService) review(ctx context.Context, prID, reviewerID, state string) (PullRequest, error) {
+	currentPR, err := pr.db.GetForUpdate(ctx, prID)
+	if err != nil {
+		pr.log.Error("failed to get pr", "error", err)
+		return PullRequest{}, err
+	}
+	if err = checkOpen(currentPR.Status); err != nil {
+		pr.log.Error("pr is not open", "pr", prID, "error", err)
+		return PullRequest{}, err
+	}
+	if !slices.Contains(currentPR.Reviewers, reviewerID) {
+		return PullRequest{}, ErrNotAssigned
+	}
+
+	err = pr.db.UpdateReviewState(ctx, prID, reviewerID, state)
+	if err != nil {
+		pr.log.Error("failed to update review state", "error", err)
+		return PullRequest{}, err
+	}
+	err = pr.addEvents(ctx, PREvent{
+		PRID:        prID,
+		Type:        EventReviewed,
+		ActorID:     reviewerID,
+		ReviewState: state,
+	})
+	if err != nil {
+		return PullRequest{}, err
+	}
+
+	pullReq, err := pr.db.Get(ctx, prID)
+	if err != nil {
+		pr.log.Error("failed to get pr", "error", err)
+		return PullRequest{}, err
+	}
+	return pullReq, nil
+}
+func (pr *PRService) Close(ctx context.Context, id string) (PullRequest, error) {
+	var pullReq PullRequest
+	err := pr.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		pullReq, err = pr.close(ctx, id)
+		return err
+	})
+	if err != nil {
+		return PullRequest{}, err
+	}
+	return pullReq, nil
+}
+func (pr *PRService) close(ctx context.Context, id string) (PullRequest, error) {
+	currentPR, err := pr.db.GetForUpdate(ctx, id)
+	if err != nil {
+		pr.log.Error("failed to get pr", "error", err)
+		return PullRequest{}, err
+	}
+	switch currentPR.Status {
+	case StatusClosed:
+		return currentPR, nil
+	case StatusMerged:
+		pr.log.Error("pr already merged", "pr", id)
+		return PullRequest{}, ErrAlredyMerged
+	}
+
+	pullReq, err := pr.db.UpdateStatus(ctx, id, StatusClosed)
+	if err != nil {
+		pr.log.Error("failed to close pr", "error", err)
+		return PullRequest{}, err
+	}
+	err = pr.addEvents(ctx, PREvent{
+		PRID:      id,
+		Type:      EventStatusChanged,
+		OldStatus: currentPR.Status,
+		NewStatus: StatusClosed,
+	})
+	if err != nil {
+		return PullRequest{}, err
+	}
+	return pullReq, nil
+}
+func (pr *PRService) Reopen(ctx context.Context, id string) (PullRequest, error) {
+	var pullReq PullRequest
+	err := pr.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		pullReq, err = pr.reopen(ctx, id)
+		return err
+	})
+	if err != nil {
+		return PullRequest{}, err
+	}
+	return pullReq, nil
+}
+func (pr *PRService) reopen(ctx context.Context, id string) (PullRequest, error) {
+	currentPR, err := pr.db.GetForUpdate(ctx, id)
+	if err != nil {
+		pr.log.Error("failed to get pr", "error", err)
+		return PullRequest{}, err
+	}
+	switch currentPR.Status {
+	case StatusOpen:
+		return currentPR, nil
+	case StatusMerged:
+		pr.log.Error("pr already merged", "pr", id)
+		return PullRequest{}, ErrAlredyMerged
+	case StatusDraft:
+		pr.log.Error("pr is a draft", "pr", id)
+		return PullRequest{}, ErrPRDraft
+	}
+
+	return pr.open(ctx, currentPR)
+}
+func (pr *PRService) MarkReady(ctx context.Context, id string) (PullRequest, error) {
+	var pullReq PullRequest
+	err := pr.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		pullReq, err = pr.markReady(ctx, id)
+		return err
+	})
+	if err != nil {
+		return PullRequest{}, err
+	}
+	return pullReq, nil
+}
+func (pr *PRService) markReady(ctx context.Context, id string) (PullRequest, error) {
+	currentPR, err := pr.db.GetForUpdate(ctx, id)
+	if err != nil {
+		pr.log.Error("failed to get pr", "error", err)
+		return PullRequest{}, err
+	}
+	switch currentPR.Status {
+	case StatusOpen:
+		return currentPR, nil
+	case StatusMerged:
+		pr.log.Error("pr already merged", "pr", id)
+		return PullRequest{}, ErrAlredyMerged
+	case StatusClosed:
+		pr.log.Error("pr closed", "pr", id)
+		return PullRequest{}, ErrPRClosed
+	}
+
+	return pr.open(ctx, currentPR)
+}
+func (pr *PRService) open(ctx context.Context, currentPR PullRequest) (PullRequest, error) {
+	// drafts get their reviewers only when they become ready
+	if len(currentPR.Reviewers) == 0 {
+		reviewers, ruleTeams, err := pr.assignReviewers(ctx, currentPR)
+		if err != nil {
+			return PullRequest{}, err
+		}
+		err = pr.db.AddReviewers(ctx, currentPR.ID, reviewers, ruleTeams)
+		if err != nil {
+			pr.log.Error("failed to add reviewers", "error", err)
+			return PullRequest{}, err
+		}
+		if err = pr.addEvents(ctx, assignedEvents(currentPR.ID, reviewers)...); err != nil {
+			return PullRequest{}, err
+		}
+	}
+
+	pullReq, err := pr.db.UpdateStatus(ctx, currentPR.ID, StatusOpen)
+	if err != nil {
+		pr.log.Error("failed to open pr", "error", err)
+		return PullRequest{}, err
+	}
+	err = pr.addEvents(ctx, PREvent{
+		PRID:      currentPR.ID,
+		Type:      EventStatusChanged,
+		OldStatus: currentPR.Status,
+		NewStatus: StatusOpen,
+	})
+	if err != nil {
+		return PullRequest{}, err
+	}
+	return pullReq, nil
+}
+func (pr *PRService) HandOff(ctx context.Context, reviewerID, fallbackTeam string) (HandoffReport, error) {
+	var report HandoffReport
+	err := pr.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		report, err = pr.handOff(ctx, reviewerID, fallbackTeam)
+		return err
+	})
+	if err != nil {
+		return HandoffReport{}, err
+	}
+	return report, nil
+}
+func (pr *PRService) handOff(ctx context.Context, reviewerID, fallbackTeam string) (HandoffReport, error) {
+	prIDs, err := pr.db.GetOpenIDsByReviewer(ctx, reviewerID)
+	if err != nil {
+		pr.log.Error("failed to get open reviews", "error", err)
+		return HandoffReport{}, err
+	}
+
+	var report HandoffReport
+	for _, prID := range prIDs {
+		_, newReviewerID, err := pr.reassign(ctx, prID, reviewerID, fallbackTeam, "", ReasonHandoff)
+		if err != nil {
+			if errors.Is(err, ErrNoCandidate) {
+				report.NoCandidate = append(report.NoCandidate, prID)
+				continue
+			}
+			return HandoffReport{}, err
+		}
+		report.Reassigned = append(report.Reassigned, Reassignment{
+			PRID:          prID,
+			OldReviewerID: reviewerID,
+			NewReviewerID: newReviewerID,
+		})
+	}
+	return report, nil
+}
+func pageLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultPageLimit
+	}
+	return min(limit, MaxPageLimit)
+}
+func (pr *PRService) ListByReviewer(ctx context.Context, filter ReviewFilter) (ReviewPage, error) {
+	if filter.Status != nil && !slices.Contains([]string{StatusOpen, StatusMerged, StatusClosed, StatusDraft}, *filter.Status) {
+		pr.log.Error("invalid pr status", "status", *filter.Status)
+		return ReviewPage{}, ErrInvalidStatus
+	}
+	limit := pageLimit(filter.Limit)
+	filter.Limit = limit + 1
+	pullReqs, err := pr.db.GetByReviewer(ctx, filter)
+	if err != nil {
+		pr.log.Error("failed to get list by reviewer", "error", err)
+		return ReviewPage{}, err
+	}
+
+	page := ReviewPage{PullRequests: pullReqs}
+	if len(pullReqs) > limit {
+		page.PullRequests = pullReqs[:limit]
+		last := page.PullRequests[limit-1]
+		page.Next = &Cursor{Time: last.CreatedAt, ID: last.ID}
+	}
+	return page, nil
+}
+func (pr *PRService) Get(ctx context.Context, id string) (PullRequest, error) {
+	pullReq, err := pr.db.Get(ctx, id)
+	if err != nil {
+		pr.log.Error("failed to get pr", "error", err)
+		return PullRequest{}, err
+	}
+	return pullReq, nil
+}
+func (pr *PRService) List(ctx context.Context, filter PRFilter) (PRPage, error) {
+	limit := pageLimit(filter.Limit)
+	// one extra row tells whether there is a next page
+	filter.Limit = limit + 1
+	pullReqs, err := pr.db.List(ctx, filter)
+	if err != nil {
+		pr.log.Error("failed to list prs", "error", err)
+		return PRPage{}, err
+	}
+
+	page := PRPage{PullRequests: pullReqs}
+	if len(pullReqs) > limit {
+		page.PullRequests = pullReqs[:limit]
+		last := page.PullRequests[limit-1]
+		page.Next = &Cursor{Time: last.CreatedAt, ID: last.ID}
+	}
+	return page, nil
+}
+func (pr *PRService) History(ctx context.Context, id string) ([]PREvent, error) {
+	if _, err := pr.db.Get(ctx, id); err != nil {
+		pr.log.Error("failed to get pr", "error", err)
+		return nil, err
+	}
+	events, err := pr.db.GetEvents(ctx, id)
+	if err != nil {
+		pr.log.Error("failed to get pr events", "error", err)
+		return nil, err
+	}
+	return events, nil
+}
+
+type StatsService struct {
+	log *slog.Logger
+	db  StatsDB
+}
+
+func NewStatsService(log *slog.Logger, db StatsDB) *StatsService {
+	return &StatsService{
+		log: log,
+		db:  db,
+	}
+}
+func (s *StatsService) Reviewers(ctx context.Context, filter StatsFilter) ([]ReviewerStats, error) {
+	stats, err := s.db.GetReviewerStats(ctx, filter)
+	if err != nil {
+		s.log.Error("failed to get reviewer stats", "error", err)
+		return nil, err
+	}
+	return stats, nil
+}
+
+type WebhookService struct {
+	log    *slog.Logger
+	db     WebhookDB
+	sender WebhookSender
+}
+
+func NewWebhookService(log *slog.Logger, db WebhookDB, sender WebhookSender) *WebhookService {
+	return &WebhookService{
+		log:    log,
+		db:     db,
+		sender: sender,
+	}
+}
+
+var webhookEvents = map[string]string{
+	EventCreated:    WebhookPRCreated,
+	EventAssigned:   WebhookReviewerAssigned,
+	EventReassigned: WebhookReviewerReassigned,
+	EventMerged:     WebhookPRMerged,
+}
+
+type WebhookPayload struct {
+	ID         string    `json:"id"`
+	Event      string    `json:"event"`
+	PRID       string    `json:"pull_request_id"`
+	ActorID    string    `json:"actor_id,omitempty"`
+	OldUserID  string    `json:"old_user_id,omitempty"`
+	NewUserID  string    `json:"new_user_id,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+func (wh *WebhookService) Create(ctx context.Context, hook Webhook) (Webhook, error) {
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		wh.log.Error("invalid webhook url", "url", hook.URL)
+		return Webhook{}, ErrInvalidWebhook
+	}
+	if len(hook.Events) == 0 {
+		wh.log.Error("webhook has no events")
+		return Webhook{}, ErrInvalidWebhook
+	}
+	for _, event := range hook.Events {
+		if !slices.Contains([]string{WebhookPRCreated, WebhookReviewerAssigned, WebhookReviewerReassigned, WebhookPRMerged}, event) {
+			wh.log.Error("unknown webhook event", "event", event)
+			return Webhook{}, ErrInvalidWebhook
+		}
+	}
+	if hook.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err = rand.Read(secret); err != nil {
+			return Webhook{}, err
+		}
+		hook.Secret = hex.EncodeToString(secret)
+	}
+
+	added, err := wh.db.Add(ctx, hook)
+	if err != nil {
+		wh.log.Error("failed to add webhook", "error", err)
+		return Webhook{}, err
+	}
+	return added, nil
+}
+func (wh *WebhookService) List(ctx context.Context) ([]Webhook, error) {
+	hooks, err := wh.db.List(ctx)
+	if err != nil {
+		wh.log.Error("failed to list webhooks", "error", err)
+		return nil, err
+	}
+	return hooks, nil
+}
+func (wh *WebhookService) Delete(ctx context.Context, id int64) error {
+	if err := wh.db.Delete(ctx, id); err != nil {
+		wh.log.Error("failed to delete webhook", "error", err)
+		return err
+	}
+	return nil
+}
+func (wh *WebhookService) Name() string {
+	return "webhook"
+}
+func (wh *WebhookService) Handle(ctx context.Context, msg OutboxMessage) error {
+	name, ok := webhookEvents[msg.Topic]
+	if !ok {
+		return nil
+	}
+	e, err := msg.PREvent()
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(WebhookPayload{
+		ID:         msg.EventID,
+		Event:      name,
+		PRID:       e.PRID,
+		ActorID:    e.ActorID,
+		OldUserID:  e.OldUserID,
+		NewUserID:  e.NewUserID,
+		Reason:     e.Reason,
+		OccurredAt: e.CreatedAt.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	return wh.db.Enqueue(ctx, msg.EventID, name, payload)
+}
+func backoff(attempts int, base, max time.Duration) time.Duration {
+	if attempts >= 16 {
+		return max
+	}
+	return min(base<<attempts, max)
+}
+func (wh *WebhookService) DeliverDue(ctx context.Context) error {
+	deliveries, err := wh.db.TakeDue(ctx, WebhookBatchSize, WebhookLease)
+	if err != nil {
+		wh.log.Error("failed to get due deliveries", "error", err)
+		return err
+	}
+
+	// deliveries are sent concurrently, so a batch takes about one sender
+	// timeout and is done before its lease runs out
+	sendErrs := make([]error, len(deliveries))
+	var wg sync.WaitGroup
+	for i, d := range deliveries {
+		wg.Go(func() {
+			sendErrs[i] = wh.sender.Send(ctx, d)
+		})
+	}
+	wg.Wait()
+
+	for i, d := range deliveries {
+		sendErr := sendErrs[i]
+		switch {
+		case sendErr == nil:
+			err = wh.db.MarkDelivered(ctx, d.ID)
+		case d.Attempts+1 >= WebhookMaxAttempts:
+			wh.log.Error("webhook delivery failed", "delivery", d.ID, "error", sendErr)
+			err = wh.db.MarkFailed(ctx, d.ID, sendErr.Error())
+		default:
+			wh.log.Debug("webhook delivery will be retried", "delivery", d.ID, "error", sendErr)
+			err = wh.db.MarkRetry(ctx, d.ID, time.Now().Add(backoff(d.Attempts, WebhookBaseBackoff, WebhookMaxBackoff)), sendErr.Error())
+		}
+		if err != nil {
+			wh.log.Error("failed to update delivery", "delivery", d.ID, "error", err)
+			return err
+		}
+	}
+	return nil
+}
+
+type outboxEvent struct {
+	ID          int64     `json:"id"`
+	PRID        string    `json:"pr_id"`
+	Type        string    `json:"type"`
+	ActorID     *string   `json:"actor_id"`
+	OldUserID   *string   `json:"old_user_id"`
+	NewUserID   *string   `json:"new_user_id"`
+	OldStatus   *string   `json:"old_status"`
+	NewStatus   *string   `json:"new_status"`
+	ReviewState *string   `json:"review_state"`
+	Reason      *string   `json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (m OutboxMessage) PREvent() (PREvent, error) {
+	var e outboxEvent
+	if err := json.Unmarshal(m.Payload, &e); err != nil {
+		return PREvent{}, err
+	}
+	deref := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	return PREvent{
+		ID:          e.ID,
+		PRID:        e.PRID,
+		Type:        e.Type,
+		ActorID:     deref(e.ActorID),
+		OldUserID:   deref(e.OldUserID),
+		NewUserID:   deref(e.NewUserID),
+		OldStatus:   deref(e.OldStatus),
+		NewStatus:   deref(e.NewStatus),
+		ReviewState: deref(e.ReviewState),
+		Reason:      deref(e.Reason),
+		CreatedAt:   e.CreatedAt,
+	}, nil
+}
+
+type OutboxDispatcher struct {
+	log   *slog.Logger
+	db    OutboxDB
+	sinks []EventSink
+}
+
+func NewOutboxDispatcher(log *slog.Logger, db OutboxDB, sinks ...EventSink) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		log:   log,
+		db:    db,
+		sinks: sinks,
+	}
+}
+
+// DispatchDue hands every due message to all sinks and marks it dispatched only
+// once each of them succeeded, so sinks must tolerate duplicates by event id.
+func (d *OutboxDispatcher) DispatchDue(ctx context.Context) error {
+	msgs, err := d.db.TakeDue(ctx, OutboxBatchSize, OutboxLease)
+	if err != nil {
+		d.log.Error("failed to get due outbox messages", "error", err)
+		return err
+	}
+
+	for _, msg := range msgs {
+		var sinkErr error
+		for _, sink := range d.sinks {
+			if sinkErr = sink.Handle(ctx, msg); sinkErr != nil {
+				sinkErr = fmt.Errorf("%s: %w", sink.Name(), sinkErr)
+				break
+			}
+		}
+
+		if sinkErr == nil {
+			err = d.db.MarkDispatched(ctx, msg.ID)
+		} else {
+			d.log.Error("outbox dispatch will be retried", "event", msg.EventID, "error", sinkErr)
+			err = d.db.MarkRetry(ctx, msg.ID, time.Now().Add(backoff(msg.Attempts, OutboxBaseBackoff, OutboxMaxBackoff)), sinkErr.Error())
+		}
+		if err != nil {
+			d.log.Error("failed to update outbox message", "event", msg.EventID, "error", err)
+			return err
+		}
+	}
+	return nil
+}
+
+type ForgeSyncService struct {
+	log    *slog.Logger
+	client ForgeClient
+}
+
+func NewForgeSyncService(log *slog.Logger, client ForgeClient) *ForgeSyncService {
+	return &ForgeSyncService{
+		log:    log,
+		client: client,
+	}
+}
+
+func (f *ForgeSyncService) Name() string {
+	return "forge"
+}
+
+// Handle mirrors assignments onto the forge. Errors are returned so the outbox
+// retries them, except for PRs the forge does not know or refuses to update.
+func (f *ForgeSyncService) Handle(ctx context.Context, msg OutboxMessage) error {
+	if msg.Topic != EventAssigned && msg.Topic != EventReassigned {
+		return nil
+	}
+	e, err := msg.PREvent()
+	if err != nil {
+		return err
+	}
+
+	if e.OldUserID != "" {
+		err = f.client.RemoveRequestedReviewers(ctx, e.PRID, []string{e.OldUserID})
+	}
+	if err == nil && e.NewUserID != "" {
+		err = f.client.RequestReviewers(ctx, e.PRID, []string{e.NewUserID})
+	}
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrForgeRejected) {
+		f.log.Error("forge sync skipped", "pr", e.PRID, "event", msg.EventID, "error", err)
+		return nil
+	}
+	return err
+}
+
+type RepositoryService struct {
+	log *slog.Logger
+	db  RepositoryDB
+}
+
+func NewRepositoryService(log *slog.Logger, db RepositoryDB) *RepositoryService {
+	return &RepositoryService{
+		log: log,
+		db:  db,
+	}
+}
+
+func validateRepository(reviewersRequired int) error {
+	if reviewersRequired < 0 {
+		return ErrInvalidReviewersCount
+	}
+	return nil
+}
+func (r *RepositoryService) Create(ctx context.Context, repo Repository) (Repository, error) {
+	if err := validateRepository(repo.ReviewersRequired); err != nil {
+		r.log.Error("invalid repository settings", "error", err)
+		return Repository{}, err
+	}
+	added, err := r.db.Add(ctx, repo)
+	if err != nil {
+		r.log.Error("failed to add repository", "error", err)
+		return Repository{}, err
+	}
+	return added, nil
+}
+func (r *RepositoryService) Get(ctx context.Context, name string) (Repository, error) {
+	repo, err := r.db.Get(ctx, name)
+	if err != nil {
+		r.log.Error("failed to get repository", "error", err)
+		return Repository{}, err
+	}
+	return repo, nil
+}
+func (r *RepositoryService) Update(ctx context.Context, name string, upd RepositoryUpdate) (Repository, error) {
+	if upd.ReviewersRequired != nil {
+		if err := validateRepository(*upd.ReviewersRequired); err != nil {
+			r.log.Error("invalid repository settings", "error", err)
+			return Repository{}, err
+		}
+	}
+	repo, err := r.db.Update(ctx, name, upd)
+	if err != nil {
+		r.log.Error("failed to update repository", "error", err)
+		return Repository{}, err
+	}
+	return repo, nil
+}
+func (r *RepositoryService) SetCodeowners(ctx context.Context, repository, content string) (Codeowners, error) {
+	co, err := ParseCodeowners(content)
+	if err != nil {
+		r.log.Error("invalid codeowners", "repository", repository, "error", err)
+		return Codeowners{}, err
+	}
+	if err = r.db.SetCodeowners(ctx, repository, content); err != nil {
+		r.log.Error("failed to set codeowners", "error", err)
+		return Codeowners{}, err
+	}
+	co.Repository = repository
+	return co, nil
+}
+func (r *RepositoryService) GetCodeowners(ctx context.Context, repository string) (Codeowners, error) {
+	content, err := r.db.GetCodeowners(ctx, repository)
+	if err != nil {
+		r.log.Error("failed to get codeowners", "error", err)
+		return Codeowners{}, err
+	}
+	co, err := ParseCodeowners(content)
+	if err != nil {
+		r.log.Error("stored codeowners are invalid", "repository", repository, "error", err)
+		return Codeowners{}, err
+	}
+	co.Repository = repository
+	return co, nil
 }
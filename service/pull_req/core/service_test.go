@@ -0,0 +1,112 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type fakeTx struct{}
+
+func (fakeTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// fakePRDB keeps a single pull request, the methods the tests do not
+// reach panic through the nil embedded interface
+type fakePRDB struct {
+	PRDB
+	pr     PullRequest
+	events []PREvent
+}
+
+func (f *fakePRDB) GetForUpdate(_ context.Context, id string) (PullRequest, error) {
+	if id != f.pr.ID {
+		return PullRequest{}, ErrNotFound
+	}
+	return f.pr, nil
+}
+
+func (f *fakePRDB) UpdateMerged(_ context.Context, id string) (PullRequest, error) {
+	f.pr.Status = StatusMerged
+	return f.pr, nil
+}
+
+func (f *fakePRDB) AddEvents(_ context.Context, events []PREvent) error {
+	f.events = append(f.events, events...)
+	return nil
+}
+
+func TestRecordMergeSkipsApprovals(t *testing.T) {
+	db := &fakePRDB{pr: PullRequest{
+		PullRequestShort:  PullRequestShort{ID: "repo#1", Status: StatusOpen},
+		Reviewers:         []string{"u2"},
+		ApprovalsRequired: 1,
+	}}
+	service := NewPRService(slog.New(slog.NewTextHandler(io.Discard, nil)), db, fakeTx{})
+
+	if _, err := service.Merge(context.Background(), "repo#1"); !errors.Is(err, ErrNotApproved) {
+		t.Fatalf("merge without approvals: got %v, want %v", err, ErrNotApproved)
+	}
+
+	merged, err := service.RecordMerge(context.Background(), "repo#1")
+	if err != nil {
+		t.Fatalf("record merge: %v", err)
+	}
+	if merged.Status != StatusMerged {
+		t.Errorf("got status %s, want %s", merged.Status, StatusMerged)
+	}
+	if len(db.events) != 1 || db.events[0].Type != EventMerged || db.events[0].OldStatus != StatusOpen {
+		t.Errorf("unexpected events %+v", db.events)
+	}
+
+	// a repeated delivery of the same webhook changes nothing
+	if _, err := service.RecordMerge(context.Background(), "repo#1"); err != nil {
+		t.Fatalf("repeated record merge: %v", err)
+	}
+	if len(db.events) != 1 {
+		t.Errorf("repeated record merge added events %+v", db.events[1:])
+	}
+}
+
+func TestMergeRequiresRuleApprovals(t *testing.T) {
+	db := &fakePRDB{pr: PullRequest{
+		PullRequestShort: PullRequestShort{ID: "repo#1", Status: StatusOpen},
+		Reviewers:        []string{"u2", "sec1"},
+		Reviews: []Review{
+			{ReviewerID: "u2", State: ReviewApproved},
+			{ReviewerID: "sec1", State: ReviewPending, RuleTeam: "security"},
+		},
+		ApprovalsRequired: 1,
+	}}
+	service := NewPRService(slog.New(slog.NewTextHandler(io.Discard, nil)), db, fakeTx{})
+
+	if _, err := service.Merge(context.Background(), "repo#1"); !errors.Is(err, ErrNotApproved) {
+		t.Fatalf("merge without the rule reviewer: got %v, want %v", err, ErrNotApproved)
+	}
+
+	db.pr.Reviews[1].State = ReviewApproved
+	merged, err := service.Merge(context.Background(), "repo#1")
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if merged.Status != StatusMerged {
+		t.Errorf("got status %s, want %s", merged.Status, StatusMerged)
+	}
+}
+
+func TestOutboxEventTimeIsUTC(t *testing.T) {
+	// the format AddEvents writes created_at in
+	msg := OutboxMessage{Payload: []byte(`{"id":1,"pr_id":"repo#1","type":"MERGED","created_at":"2026-10-17T09:30:00.123456Z"}`)}
+	e, err := msg.PREvent()
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := time.Date(2026, 10, 17, 9, 30, 0, 123456000, time.UTC)
+	if !e.CreatedAt.Equal(want) {
+		t.Errorf("got %v, want %v", e.CreatedAt, want)
+	}
+}
@@ -9,10 +9,18 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
+	"time"
 	"pull_req/pull_req/adapters/rest"
 	"pull_req/pull_req/config"
 	"pull_req/pull_req/core"
 	"pull_req/pull_req/adapters/db"
+	"pull_req/pull_req/adapters/webhook"
+	"pull_req/pull_req/adapters/outbox"
+	"pull_req/pull_req/adapters/forge"
+	"pull_req/pull_req/adapters/gitea"
+	"pull_req/pull_req/adapters/github"
+	"pull_req/pull_req/adapters/gitlab"
 )
 
 func main() {
@@ -37,26 +45,106 @@ func run(cfg config.Config, log *slog.Logger) error {
 	if err != nil {
 		return fmt.Errorf("failed to create db: %v", err)
 	}
+	webhookDB := db.NewWebhookDB(storage)
+	webhookService := core.NewWebhookService(log, webhookDB, webhook.NewSender(cfg.WebhookTimeout))
+	if cfg.WebhookTimeout >= core.WebhookLease {
+		log.Error("webhook timeout exceeds the delivery lease, deliveries may be sent twice",
+			"timeout", cfg.WebhookTimeout, "lease", core.WebhookLease)
+	}
+
+	prDB := db.NewPRDB(storage)
+	prService := core.NewPRService(log, prDB, storage)
+
+	outboxDB := db.NewOutboxDB(storage)
+	sinks := []core.EventSink{webhookService, outbox.NewLogSink(log)}
+	if cfg.Github.Token != "" {
+		client := github.NewClient(cfg.Github.APIURL, cfg.Github.Token, cfg.Github.Logins, cfg.WebhookTimeout)
+		sinks = append(sinks, core.NewForgeSyncService(log, client))
+	}
+	dispatcher := core.NewOutboxDispatcher(log, outboxDB, sinks...)
+
 	teamDB := db.NewTeamDB(storage)
-	teamService := core.NewTeamService(log, teamDB)
+	teamService := core.NewTeamService(log, teamDB, storage, prService, cfg.FallbackTeam)
+	if cfg.FallbackTeam != "" {
+		// the team may still be created later, so this is not fatal
+		if _, err := teamService.Get(context.Background(), cfg.FallbackTeam); err != nil {
+			log.Error("fallback team is not available", "team", cfg.FallbackTeam, "error", err)
+		}
+	}
 
 	userDB := db.NewUserDB(storage)
-	userService := core.NewUserService(log, userDB)
+	userService := core.NewUserService(log, userDB, storage, prService)
 
-	prDB := db.NewPRDB(storage)
-	prService := core.NewPRService(log, prDB)
+	repositoryDB := db.NewRepositoryDB(storage)
+	repositoryService := core.NewRepositoryService(log, repositoryDB)
+
+	statsDB := db.NewStatsDB(storage)
+	statsService := core.NewStatsService(log, statsDB)
 
 	mux := http.NewServeMux()
 	mux.Handle("POST /team/add", rest.NewAddTeamHandler(log, teamService))
 	mux.Handle("GET /team/get", rest.NewGetTeamHandler(log, teamService))
-
+	mux.Handle("POST /team/setStrategy", rest.NewSetStrategyHandler(log, teamService))
+	mux.Handle("POST /team/update", rest.NewUpdateTeamHandler(log, teamService))
+	mux.Handle("POST /team/deactivateUsers", rest.NewDeactivateUsersHandler(log, teamService))
+	mux.Handle("POST /team/addMember", rest.NewAddMemberHandler(log, teamService))
+	mux.Handle("POST /team/removeMember", rest.NewRemoveMemberHandler(log, teamService))
+	mux.Handle("POST /team/rename", rest.NewRenameTeamHandler(log, teamService))
+	mux.Handle("POST /team/delete", rest.NewDeleteTeamHandler(log, teamService))
+	mux.Handle("POST /team/setReviewRule", rest.NewSetReviewRuleHandler(log, teamService))
+	mux.Handle("POST /team/removeReviewRule", rest.NewRemoveReviewRuleHandler(log, teamService))
+	mux.Handle("GET /team/getReviewRules", rest.NewGetReviewRulesHandler(log, teamService))
+
+	mux.Handle("GET /users/get", rest.NewGetUserHandler(log, userService))
+	mux.Handle("GET /users/list", rest.NewListUsersHandler(log, userService))
+	mux.Handle("POST /users/update", rest.NewUpdateUserHandler(log, userService))
+	mux.Handle("POST /users/delete", rest.NewDeleteUserHandler(log, userService))
 	mux.Handle("POST /users/setIsActive", rest.NewSetIsActiveHandler(log, userService))
+	mux.Handle("POST /users/addAbsence", rest.NewAddAbsenceHandler(log, userService))
+	mux.Handle("GET /users/getAbsences", rest.NewGetAbsencesHandler(log, userService))
+	mux.Handle("POST /users/removeAbsence", rest.NewRemoveAbsenceHandler(log, userService))
+	mux.Handle("POST /users/moveTeam", rest.NewMoveTeamHandler(log, userService))
 
 	mux.Handle("POST /pullRequest/create", rest.NewCreatePRHandler(log, prService))
 	mux.Handle("POST /pullRequest/merge", rest.NewMergePRHandler(log, prService))
 	mux.Handle("POST /pullRequest/reassign", rest.NewReassignPRHandler(log, prService))
+	mux.Handle("POST /pullRequest/review", rest.NewReviewPRHandler(log, prService))
+	mux.Handle("POST /pullRequest/close", rest.NewClosePRHandler(log, prService))
+	mux.Handle("POST /pullRequest/reopen", rest.NewReopenPRHandler(log, prService))
+	mux.Handle("POST /pullRequest/markReady", rest.NewMarkReadyPRHandler(log, prService))
+	mux.Handle("GET /pullRequest/get", rest.NewGetPRHandler(log, prService))
+	mux.Handle("GET /pullRequest/list", rest.NewListPRHandler(log, prService))
+	mux.Handle("GET /pullRequest/history", rest.NewPRHistoryHandler(log, prService))
 	mux.Handle("GET /users/getReview", rest.NewGetReviewHandler(log, prService))
 
+	mux.Handle("POST /repositories/add", rest.NewAddRepositoryHandler(log, repositoryService))
+	mux.Handle("GET /repositories/get", rest.NewGetRepositoryHandler(log, repositoryService))
+	mux.Handle("POST /repositories/update", rest.NewUpdateRepositoryHandler(log, repositoryService))
+	mux.Handle("POST /repositories/setCodeowners", rest.NewSetCodeownersHandler(log, repositoryService))
+	mux.Handle("GET /repositories/getCodeowners", rest.NewGetCodeownersHandler(log, repositoryService))
+
+	mux.Handle("GET /stats/reviewers", rest.NewReviewerStatsHandler(log, statsService))
+
+	mux.Handle("POST /webhooks", rest.NewCreateWebhookHandler(log, webhookService))
+	mux.Handle("GET /webhooks", rest.NewListWebhooksHandler(log, webhookService))
+	mux.Handle("DELETE /webhooks", rest.NewDeleteWebhookHandler(log, webhookService))
+
+	forges := []struct {
+		cfg    config.ForgeConfig
+		parser forge.Parser
+	}{
+		{cfg.Github, github.NewParser(cfg.Github.Secret)},
+		{cfg.Gitlab, gitlab.NewParser(cfg.Gitlab.Secret)},
+		{cfg.Gitea, gitea.NewParser(cfg.Gitea.Secret)},
+	}
+	for _, f := range forges {
+		if f.cfg.Secret == "" {
+			log.Info("forge webhook secret is not set, inbound webhooks are disabled", "forge", f.parser.Name())
+			continue
+		}
+		mux.Handle("POST /"+f.parser.Name()+"/webhook", forge.NewWebhookHandler(log, prService, f.parser, f.cfg.Logins))
+	}
+
 	server := http.Server{
 		Addr:        cfg.HTTPConfig.Address,
 		ReadTimeout: cfg.HTTPConfig.Timeout,
@@ -66,6 +154,58 @@ func run(cfg config.Config, log *slog.Logger) error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer stop()
 
+	go func() {
+		ticker := time.NewTicker(cfg.AbsenceCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := userService.HandOffAbsentReviews(ctx); err != nil {
+					log.Error("absence handoff failed", "error", err)
+				}
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(cfg.WebhookInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// as with the outbox, a started batch is finished on shutdown
+				if err := webhookService.DeliverDue(context.WithoutCancel(ctx)); err != nil {
+					log.Error("webhook delivery failed", "error", err)
+				}
+			}
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(cfg.OutboxInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// let the current batch finish on shutdown instead of leaving it leased
+				if err := dispatcher.DispatchDue(context.WithoutCancel(ctx)); err != nil {
+					log.Error("outbox dispatch failed", "error", err)
+				}
+			}
+		}
+	}()
+
 	go func() {
 		<-ctx.Done()
 		log.Debug("shutting down server")
@@ -81,6 +221,9 @@ func run(cfg config.Config, log *slog.Logger) error {
 		}
 	}
 
+	log.Debug("waiting for outbox dispatcher and webhook delivery")
+	wg.Wait()
+
 	return nil
 }
 
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"math/rand"
 	"net/http"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
@@ -26,14 +28,20 @@ type TeamMember struct {
 }
 
 type Team struct {
-	TeamName string       `json:"team_name"`
-	Members  []TeamMember `json:"members"`
+	TeamName          string       `json:"team_name"`
+	ReviewersRequired int          `json:"reviewers_required,omitempty"`
+	ApprovalsRequired int          `json:"approvals_required,omitempty"`
+	Members           []TeamMember `json:"members"`
 }
 
 type PullRequestReq struct {
-	PRID     string `json:"pull_request_id"`
-	PRName   string `json:"pull_request_name"`
-	AuthorID string `json:"author_id"`
+	PRID           string `json:"pull_request_id"`
+	PRName         string `json:"pull_request_name"`
+	AuthorID       string `json:"author_id"`
+	ReviewersCount int      `json:"reviewers_count,omitempty"`
+	IsDraft        bool     `json:"is_draft,omitempty"`
+	Repository     string   `json:"repository,omitempty"`
+	ChangedFiles   []string `json:"changed_files,omitempty"`
 }
 
 type PullRequestResp struct {
@@ -56,10 +64,25 @@ type ReassignResp struct {
 	} `json:"pr"`
 }
 
+type GetReviewResp struct {
+	UserID string `json:"user_id"`
+	PRs    []struct {
+		ID     string `json:"pull_request_id"`
+		Status string `json:"status"`
+	} `json:"pull_requests"`
+	NextCursor string `json:"next_cursor"`
+}
+
 type MergeReq struct {
 	PRID string `json:"pull_request_id"`
 }
 
+type ReviewReq struct {
+	PRID       string `json:"pull_request_id"`
+	ReviewerID string `json:"reviewer_id"`
+	State      string `json:"state"`
+}
+
 func TestHappyPath_CreatePR(t *testing.T) {
 	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
 	suffix := fmt.Sprintf("%d", rnd.Int())
@@ -165,6 +188,1002 @@ func TestMergeLifecycle(t *testing.T) {
 	require.Equal(t, http.StatusConflict, httpResp.StatusCode, "Should not allow reassign on merged PR")
 }
 
+func TestReviewersCount(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	suffix := fmt.Sprintf("%d", rnd.Int())
+
+	teamName := "team_count_" + suffix
+	users := []string{"c1_" + suffix, "c2_" + suffix, "c3_" + suffix, "c4_" + suffix}
+
+	members := make([]TeamMember, len(users))
+	for i, u := range users {
+		members[i] = TeamMember{UserID: u, Username: "User", IsActive: true}
+	}
+	createTeam(t, Team{TeamName: teamName, ReviewersRequired: 1, Members: members})
+
+	prResp := createPR(t, PullRequestReq{
+		PRID:     "pr_count_team_" + suffix,
+		PRName:   "Team default",
+		AuthorID: users[0],
+	})
+	require.Len(t, prResp.PR.Reviewers, 1, "Should assign team reviewers_required reviewers")
+
+	prResp = createPR(t, PullRequestReq{
+		PRID:           "pr_count_override_" + suffix,
+		PRName:         "Override",
+		AuthorID:       users[0],
+		ReviewersCount: 3,
+	})
+	require.Len(t, prResp.PR.Reviewers, 3, "Per-PR override should win over team setting")
+	require.NotContains(t, prResp.PR.Reviewers, users[0], "Author should not be a reviewer")
+}
+
+func TestMergeRequiresApprovals(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	suffix := fmt.Sprintf("%d", rnd.Int())
+
+	teamName := "team_approve_" + suffix
+	author, rev1, rev2 := "a1_"+suffix, "a2_"+suffix, "a3_"+suffix
+	createTeam(t, Team{
+		TeamName:          teamName,
+		ApprovalsRequired: 1,
+		Members: []TeamMember{
+			{UserID: author, IsActive: true, Username: "A"},
+			{UserID: rev1, IsActive: true, Username: "B"},
+			{UserID: rev2, IsActive: true, Username: "C"},
+		},
+	})
+
+	prID := "pr_approve_" + suffix
+	prResp := createPR(t, PullRequestReq{PRID: prID, PRName: "Needs approval", AuthorID: author})
+	require.Len(t, prResp.PR.Reviewers, 2)
+
+	_, status := sendMergeRequest(t, prID)
+	require.Equal(t, http.StatusConflict, status, "Merge without approvals should be refused")
+
+	status = sendReview(t, ReviewReq{PRID: prID, ReviewerID: author, State: "APPROVED"})
+	require.Equal(t, http.StatusConflict, status, "Author is not a reviewer")
+
+	status = sendReview(t, ReviewReq{PRID: prID, ReviewerID: prResp.PR.Reviewers[0], State: "APPROVED"})
+	require.Equal(t, http.StatusOK, status)
+
+	status = sendReview(t, ReviewReq{PRID: prID, ReviewerID: prResp.PR.Reviewers[1], State: "CHANGES_REQUESTED"})
+	require.Equal(t, http.StatusOK, status)
+
+	_, status = sendMergeRequest(t, prID)
+	require.Equal(t, http.StatusConflict, status, "Requested changes should block the merge")
+
+	status = sendReview(t, ReviewReq{PRID: prID, ReviewerID: prResp.PR.Reviewers[1], State: "COMMENTED"})
+	require.Equal(t, http.StatusOK, status)
+
+	mergePR(t, prID)
+
+	soloTeam := "team_approve_solo_" + suffix
+	soloAuthor := "a_solo_" + suffix
+	createTeam(t, Team{
+		TeamName:          soloTeam,
+		ApprovalsRequired: 1,
+		Members:           []TeamMember{{UserID: soloAuthor, IsActive: true, Username: "A"}},
+	})
+	soloPR := "pr_approve_solo_" + suffix
+	prResp = createPR(t, PullRequestReq{PRID: soloPR, PRName: "No reviewers", AuthorID: soloAuthor})
+	require.Empty(t, prResp.PR.Reviewers)
+
+	_, status = sendMergeRequest(t, soloPR)
+	require.Equal(t, http.StatusConflict, status, "A PR without reviewers can not skip required approvals")
+}
+
+func TestConcurrentReassign(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	suffix := fmt.Sprintf("%d", rnd.Int())
+
+	teamName := "team_race_" + suffix
+	users := make([]string, 8)
+	members := make([]TeamMember, len(users))
+	for i := range users {
+		users[i] = fmt.Sprintf("r%d_%s", i, suffix)
+		members[i] = TeamMember{UserID: users[i], Username: "User", IsActive: true}
+	}
+	createTeam(t, Team{TeamName: teamName, Members: members})
+
+	prID := "pr_race_" + suffix
+	prResp := createPR(t, PullRequestReq{PRID: prID, PRName: "Race", AuthorID: users[0]})
+	require.Len(t, prResp.PR.Reviewers, 2)
+
+	const attempts = 10
+	oldReviewer := prResp.PR.Reviewers[0]
+	var wg sync.WaitGroup
+	statuses := make(chan int, attempts)
+	for range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, _ := json.Marshal(ReassignReq{PRID: prID, OldUserID: oldReviewer})
+			resp, err := client.Post(baseURL+"/pullRequest/reassign", "application/json", bytes.NewBuffer(body))
+			if err != nil {
+				statuses <- 0
+				return
+			}
+			resp.Body.Close()
+			statuses <- resp.StatusCode
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	succeeded := 0
+	for status := range statuses {
+		if status == http.StatusOK {
+			succeeded++
+			continue
+		}
+		require.Equal(t, http.StatusConflict, status, "Losing reassigns should conflict")
+	}
+	require.Equal(t, 1, succeeded, "Reviewer can be replaced only once")
+
+	var reviewers []string
+	for _, u := range users {
+		for _, pr := range getReview(t, u).PRs {
+			if pr.ID == prID {
+				reviewers = append(reviewers, u)
+			}
+		}
+	}
+	require.Len(t, reviewers, 2, "PR should still have exactly 2 distinct reviewers")
+	require.NotContains(t, reviewers, users[0], "Author must not be assigned")
+	require.NotContains(t, reviewers, oldReviewer, "Replaced reviewer must be removed")
+	require.Contains(t, reviewers, prResp.PR.Reviewers[1], "Untouched reviewer must stay")
+}
+
+func TestDraftAndCloseLifecycle(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	suffix := fmt.Sprintf("%d", rnd.Int())
+
+	teamName := "team_draft_" + suffix
+	u1, u2, u3 := "d1_"+suffix, "d2_"+suffix, "d3_"+suffix
+	createTeam(t, Team{
+		TeamName: teamName,
+		Members: []TeamMember{
+			{UserID: u1, IsActive: true, Username: "A"},
+			{UserID: u2, IsActive: true, Username: "B"},
+			{UserID: u3, IsActive: true, Username: "C"},
+		},
+	})
+
+	prID := "pr_draft_" + suffix
+	prResp := createPR(t, PullRequestReq{PRID: prID, PRName: "WIP", AuthorID: u1, IsDraft: true})
+	require.Equal(t, "DRAFT", prResp.PR.Status)
+	require.Empty(t, prResp.PR.Reviewers, "Draft should not get reviewers")
+
+	_, status := sendMergeRequest(t, prID)
+	require.Equal(t, http.StatusConflict, status, "Draft can not be merged")
+
+	resp, status := sendStatusChange(t, "/pullRequest/markReady", prID)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "OPEN", resp.PR.Status)
+	require.Len(t, resp.PR.Reviewers, 2, "Reviewers should be assigned when draft becomes ready")
+
+	resp, status = sendStatusChange(t, "/pullRequest/close", prID)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "CLOSED", resp.PR.Status)
+
+	_, status = sendMergeRequest(t, prID)
+	require.Equal(t, http.StatusConflict, status, "Closed PR can not be merged")
+
+	resp, status = sendStatusChange(t, "/pullRequest/reopen", prID)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "OPEN", resp.PR.Status)
+
+	mergePR(t, prID)
+}
+
+func TestAbsentUserIsNotAssigned(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	suffix := fmt.Sprintf("%d", rnd.Int())
+
+	teamName := "team_absence_" + suffix
+	author, present, absent := "o1_"+suffix, "o2_"+suffix, "o3_"+suffix
+	createTeam(t, Team{
+		TeamName: teamName,
+		Members: []TeamMember{
+			{UserID: author, IsActive: true, Username: "A"},
+			{UserID: present, IsActive: true, Username: "B"},
+			{UserID: absent, IsActive: true, Username: "C"},
+		},
+	})
+
+	body, err := json.Marshal(map[string]any{
+		"user_id": absent,
+		"from":    time.Now().Add(-time.Hour),
+		"to":      time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	resp, err := client.Post(baseURL+"/users/addAbsence", "application/json", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	prResp := createPR(t, PullRequestReq{PRID: "pr_absence_" + suffix, PRName: "Vacation", AuthorID: author})
+	require.Equal(t, []string{present}, prResp.PR.Reviewers, "Absent user should not be assigned")
+
+	resp, err = client.Get(baseURL + "/users/getAbsences?user_id=o_missing_" + suffix)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestDeactivateReassignsReviews(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	suffix := fmt.Sprintf("%d", rnd.Int())
+
+	teamName := "team_deactivate_" + suffix
+	users := []string{"x1_" + suffix, "x2_" + suffix, "x3_" + suffix, "x4_" + suffix}
+	members := make([]TeamMember, len(users))
+	for i, u := range users {
+		members[i] = TeamMember{UserID: u, Username: "User", IsActive: true}
+	}
+	createTeam(t, Team{TeamName: teamName, Members: members})
+
+	prID := "pr_deactivate_" + suffix
+	prResp := createPR(t, PullRequestReq{PRID: prID, PRName: "Handoff", AuthorID: users[0]})
+	require.Len(t, prResp.PR.Reviewers, 2)
+	leaving := prResp.PR.Reviewers[0]
+
+	body, err := json.Marshal(map[string]any{
+		"user_id":          leaving,
+		"is_active":        false,
+		"reassign_reviews": true,
+	})
+	require.NoError(t, err)
+	resp, err := client.Post(baseURL+"/users/setIsActive", "application/json", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result struct {
+		Handoff struct {
+			Reassigned []struct {
+				PRID       string `json:"pull_request_id"`
+				ReplacedBy string `json:"replaced_by"`
+			} `json:"reassigned"`
+			NoCandidate []string `json:"no_candidate"`
+		} `json:"handoff"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	require.Len(t, result.Handoff.Reassigned, 1)
+	require.Equal(t, prID, result.Handoff.Reassigned[0].PRID)
+	require.NotEqual(t, leaving, result.Handoff.Reassigned[0].ReplacedBy)
+	require.Empty(t, result.Handoff.NoCandidate)
+	require.Empty(t, getReview(t, leaving).PRs, "Deactivated user should keep no reviews")
+}
+
+func TestBulkDeactivateTeamUsers(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	suffix := fmt.Sprintf("%d", rnd.Int())
+
+	teamName := "team_bulk_" + suffix
+	users := []string{"b1_" + suffix, "b2_" + suffix, "b3_" + suffix, "b4_" + suffix, "b5_" + suffix}
+	members := make([]TeamMember, len(users))
+	for i, u := range users {
+		members[i] = TeamMember{UserID: u, Username: "User", IsActive: true}
+	}
+	createTeam(t, Team{TeamName: teamName, Members: members})
+
+	prID := "pr_bulk_" + suffix
+	prResp := createPR(t, PullRequestReq{PRID: prID, PRName: "Bulk", AuthorID: users[0]})
+	require.Len(t, prResp.PR.Reviewers, 2)
+	leaving := prResp.PR.Reviewers
+
+	body, err := json.Marshal(map[string]any{
+		"team_name": teamName,
+		"user_ids":  leaving,
+	})
+	require.NoError(t, err)
+	resp, err := client.Post(baseURL+"/team/deactivateUsers", "application/json", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result struct {
+		Users []struct {
+			UserID     string `json:"user_id"`
+			Reassigned []struct {
+				ReplacedBy string `json:"replaced_by"`
+			} `json:"reassigned"`
+		} `json:"users"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	require.Len(t, result.Users, 2)
+	for _, u := range result.Users {
+		require.Len(t, u.Reassigned, 1)
+		require.NotContains(t, leaving, u.Reassigned[0].ReplacedBy, "Deactivated user should not be picked")
+		require.Empty(t, getReview(t, u.UserID).PRs)
+	}
+}
+
+func TestMoveTeam(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	suffix := fmt.Sprintf("%d", rnd.Int())
+
+	oldTeam := "team_move_old_" + suffix
+	users := []string{"m1_" + suffix, "m2_" + suffix, "m3_" + suffix, "m4_" + suffix}
+	members := make([]TeamMember, len(users))
+	for i, u := range users {
+		members[i] = TeamMember{UserID: u, Username: "User", IsActive: true}
+	}
+	createTeam(t, Team{TeamName: oldTeam, Members: members})
+
+	body, err := json.Marshal(Team{TeamName: "team_move_new_" + suffix, Members: members[:1]})
+	require.NoError(t, err)
+	resp, err := client.Post(baseURL+"/team/add", "application/json", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode, "Members of another team should not be moved silently")
+
+	newTeam := "team_move_new2_" + suffix
+	createTeam(t, Team{TeamName: newTeam, Members: []TeamMember{{UserID: "m5_" + suffix, Username: "User", IsActive: true}}})
+
+	prResp := createPR(t, PullRequestReq{PRID: "pr_move_" + suffix, PRName: "Move", AuthorID: users[0]})
+	require.Len(t, prResp.PR.Reviewers, 2)
+	moving := prResp.PR.Reviewers[0]
+
+	body, err = json.Marshal(map[string]any{
+		"user_id":       moving,
+		"team_name":     newTeam,
+		"review_policy": "REASSIGN_OLD_TEAM",
+	})
+	require.NoError(t, err)
+	resp, err = client.Post(baseURL+"/users/moveTeam", "application/json", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result struct {
+		User struct {
+			TeamName string `json:"team_name"`
+		} `json:"user"`
+		Handoff struct {
+			Reassigned []struct {
+				ReplacedBy string `json:"replaced_by"`
+			} `json:"reassigned"`
+		} `json:"handoff"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	require.Equal(t, newTeam, result.User.TeamName)
+	require.Len(t, result.Handoff.Reassigned, 1)
+	require.Contains(t, users, result.Handoff.Reassigned[0].ReplacedBy, "Replacement should come from the old team")
+	require.Empty(t, getReview(t, moving).PRs)
+}
+
+func TestTeamMembership(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	suffix := fmt.Sprintf("%d", rnd.Int())
+
+	teamName := "team_members_" + suffix
+	users := []string{"t1_" + suffix, "t2_" + suffix, "t3_" + suffix}
+	members := make([]TeamMember, len(users))
+	for i, u := range users {
+		members[i] = TeamMember{UserID: u, Username: "User", IsActive: true}
+	}
+	createTeam(t, Team{TeamName: teamName, Members: members})
+
+	newcomer := "t4_" + suffix
+	status := postJSON(t, "/team/addMember", map[string]any{
+		"team_name": teamName,
+		"user_id":   newcomer,
+		"username":  "Newcomer",
+		"is_active": true,
+	})
+	require.Equal(t, http.StatusOK, status)
+
+	prResp := createPR(t, PullRequestReq{PRID: "pr_members_" + suffix, PRName: "Members", AuthorID: users[0]})
+	require.Len(t, prResp.PR.Reviewers, 2)
+	leaving := prResp.PR.Reviewers[0]
+
+	status = postJSON(t, "/team/removeMember", map[string]any{"team_name": teamName, "user_id": leaving})
+	require.Equal(t, http.StatusOK, status)
+	require.Empty(t, getReview(t, leaving).PRs, "Removed member should keep no reviews")
+
+	status = postJSON(t, "/team/removeMember", map[string]any{"team_name": teamName, "user_id": leaving})
+	require.Equal(t, http.StatusNotFound, status)
+
+	renamed := teamName + "_renamed"
+	status = postJSON(t, "/team/rename", map[string]any{"team_name": teamName, "new_team_name": renamed})
+	require.Equal(t, http.StatusOK, status)
+
+	status = postJSON(t, "/team/delete", map[string]any{"team_name": renamed})
+	require.Equal(t, http.StatusOK, status)
+
+	// PRs of a deleted team should stay
+	mergePR(t, prResp.PR.ID)
+}
+
+func TestRemovedAuthorKeepsPRs(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	suffix := fmt.Sprintf("%d", rnd.Int())
+
+	teamName := "team_left_" + suffix
+	author, rev1, rev2 := "l1_"+suffix, "l2_"+suffix, "l3_"+suffix
+	createTeam(t, Team{
+		TeamName:          teamName,
+		ApprovalsRequired: 1,
+		Members: []TeamMember{
+			{UserID: author, IsActive: true, Username: "A"},
+			{UserID: rev1, IsActive: true, Username: "B"},
+			{UserID: rev2, IsActive: true, Username: "C"},
+		},
+	})
+
+	prID := "pr_left_" + suffix
+	prResp := createPR(t, PullRequestReq{PRID: prID, PRName: "Left", AuthorID: author})
+	require.Len(t, prResp.PR.Reviewers, 2)
+	draftID := "pr_left_draft_" + suffix
+	createPR(t, PullRequestReq{PRID: draftID, PRName: "Left draft", AuthorID: author, IsDraft: true})
+
+	status := postJSON(t, "/team/removeMember", map[string]any{"team_name": teamName, "user_id": author})
+	require.Equal(t, http.StatusOK, status)
+
+	_, status = sendMergeRequest(t, prID)
+	require.Equal(t, http.StatusConflict, status, "Approvals required at creation should still apply")
+
+	status = sendReview(t, ReviewReq{PRID: prID, ReviewerID: prResp.PR.Reviewers[0], State: "APPROVED"})
+	require.Equal(t, http.StatusOK, status)
+	mergePR(t, prID)
+
+	_, status = sendStatusChange(t, "/pullRequest/markReady", draftID)
+	require.Equal(t, http.StatusOK, status)
+}
+
+func TestUserCRUD(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	suffix := fmt.Sprintf("%d", rnd.Int())
+
+	teamName := "team_users_" + suffix
+	users := []string{"c1_" + suffix, "c2_" + suffix, "c3_" + suffix}
+	members := make([]TeamMember, len(users))
+	for i, u := range users {
+		members[i] = TeamMember{UserID: u, Username: "User", IsActive: true}
+	}
+	members[2].IsActive = false
+	createTeam(t, Team{TeamName: teamName, Members: members})
+
+	status := postJSON(t, "/users/update", map[string]any{"user_id": users[1], "username": "Renamed"})
+	require.Equal(t, http.StatusOK, status)
+
+	resp, err := client.Get(baseURL + "/users/get?user_id=" + users[1])
+	require.NoError(t, err)
+	var user struct {
+		User TeamMember `json:"user"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&user))
+	resp.Body.Close()
+	require.Equal(t, "Renamed", user.User.Username)
+
+	resp, err = client.Get(baseURL + "/users/list?is_active=true&team_name=" + teamName)
+	require.NoError(t, err)
+	var list struct {
+		Users []TeamMember `json:"users"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	resp.Body.Close()
+	require.Len(t, list.Users, 2)
+
+	createPR(t, PullRequestReq{PRID: "pr_users_" + suffix, PRName: "Users", AuthorID: users[0]})
+
+	status = postJSON(t, "/users/delete", map[string]any{"user_id": users[0]})
+	require.Equal(t, http.StatusConflict, status, "Authors of open PRs should not be deleted")
+
+	// a closed PR stays in the history without its author
+	_, status = sendStatusChange(t, "/pullRequest/close", "pr_users_"+suffix)
+	require.Equal(t, http.StatusOK, status)
+	status = postJSON(t, "/users/delete", map[string]any{"user_id": users[0]})
+	require.Equal(t, http.StatusOK, status)
+
+	resp, err = client.Get(baseURL + "/pullRequest/get?pull_request_id=pr_users_" + suffix)
+	require.NoError(t, err)
+	var kept struct {
+		PR struct {
+			AuthorID string `json:"author_id"`
+			Status   string `json:"status"`
+		} `json:"pull_request"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&kept))
+	resp.Body.Close()
+	require.Equal(t, "CLOSED", kept.PR.Status)
+	require.Empty(t, kept.PR.AuthorID)
+
+	status = postJSON(t, "/users/delete", map[string]any{"user_id": users[1]})
+	require.Equal(t, http.StatusOK, status)
+	require.Empty(t, getReview(t, users[1]).PRs)
+
+	resp, err = client.Get(baseURL + "/users/get?user_id=" + users[1])
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestListPullRequests(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	suffix := fmt.Sprintf("%d", rnd.Int())
+
+	teamName := "team_list_" + suffix
+	users := []string{"l1_" + suffix, "l2_" + suffix, "l3_" + suffix}
+	members := make([]TeamMember, len(users))
+	for i, u := range users {
+		members[i] = TeamMember{UserID: u, Username: "User", IsActive: true}
+	}
+	createTeam(t, Team{TeamName: teamName, Members: members})
+
+	for i := 0; i < 3; i++ {
+		createPR(t, PullRequestReq{PRID: fmt.Sprintf("pr_list_%d_%s", i, suffix), PRName: "List", AuthorID: users[0]})
+	}
+
+	resp, err := client.Get(baseURL + "/pullRequest/get?pull_request_id=pr_list_0_" + suffix)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	type listResp struct {
+		PRs []struct {
+			ID string `json:"pull_request_id"`
+		} `json:"pull_requests"`
+		NextCursor string `json:"next_cursor"`
+	}
+	list := func(query string) listResp {
+		resp, err := client.Get(baseURL + "/pullRequest/list?" + query)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var result listResp
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		return result
+	}
+
+	first := list("limit=2&team_name=" + teamName)
+	require.Len(t, first.PRs, 2)
+	require.NotEmpty(t, first.NextCursor)
+
+	second := list("limit=2&team_name=" + teamName + "&cursor=" + first.NextCursor)
+	require.Len(t, second.PRs, 1)
+	require.Empty(t, second.NextCursor)
+	require.NotContains(t, []string{first.PRs[0].ID, first.PRs[1].ID}, second.PRs[0].ID)
+}
+
+func TestGetReviewPagination(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	suffix := fmt.Sprintf("%d", rnd.Int())
+
+	teamName := "team_review_page_" + suffix
+	users := []string{"g1_" + suffix, "g2_" + suffix, "g3_" + suffix}
+	members := make([]TeamMember, len(users))
+	for i, u := range users {
+		members[i] = TeamMember{UserID: u, Username: "User", IsActive: true}
+	}
+	createTeam(t, Team{TeamName: teamName, Members: members})
+
+	for i := 0; i < 3; i++ {
+		createPR(t, PullRequestReq{PRID: fmt.Sprintf("pr_review_page_%d_%s", i, suffix), PRName: "Page", AuthorID: users[0]})
+	}
+	mergePR(t, "pr_review_page_0_"+suffix)
+
+	first := getReviewPage(t, "limit=2&user_id="+users[1])
+	require.Len(t, first.PRs, 2)
+	require.NotEmpty(t, first.NextCursor)
+
+	second := getReviewPage(t, "limit=2&user_id="+users[1]+"&cursor="+first.NextCursor)
+	require.Len(t, second.PRs, 1)
+	require.Empty(t, second.NextCursor)
+
+	open := getReviewPage(t, "status=OPEN&user_id="+users[1])
+	require.Len(t, open.PRs, 2)
+
+	resp, err := client.Get(baseURL + "/users/getReview?status=bogus&user_id=" + users[1])
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestReviewerStats(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	suffix := fmt.Sprintf("%d", rnd.Int())
+
+	teamName := "team_stats_" + suffix
+	users := []string{"s1_" + suffix, "s2_" + suffix, "s3_" + suffix, "s4_" + suffix}
+	members := make([]TeamMember, len(users))
+	for i, u := range users {
+		members[i] = TeamMember{UserID: u, Username: "User", IsActive: true}
+	}
+	createTeam(t, Team{TeamName: teamName, Members: members})
+
+	prID := "pr_stats_" + suffix
+	prResp := createPR(t, PullRequestReq{PRID: prID, PRName: "Stats", AuthorID: users[0]})
+	require.Len(t, prResp.PR.Reviewers, 2)
+	oldReviewer := prResp.PR.Reviewers[0]
+	reassignResp := reassignPR(t, ReassignReq{PRID: prID, OldUserID: oldReviewer})
+	mergePR(t, prID)
+
+	resp, err := client.Get(baseURL + "/stats/reviewers?team_name=" + teamName)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result struct {
+		Reviewers []struct {
+			UserID         string   `json:"user_id"`
+			Assigned       int      `json:"assigned"`
+			Merged         int      `json:"merged"`
+			ReassignedAway int      `json:"reassigned_away"`
+			AvgTimeToMerge *float64 `json:"avg_time_to_merge_seconds"`
+		} `json:"reviewers"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	require.Len(t, result.Reviewers, len(users))
+
+	for _, r := range result.Reviewers {
+		switch r.UserID {
+		case oldReviewer:
+			require.Equal(t, 1, r.Assigned)
+			require.Equal(t, 1, r.ReassignedAway)
+			require.Zero(t, r.Merged)
+		case reassignResp.ReplacedBy:
+			require.Equal(t, 1, r.Merged)
+			require.NotNil(t, r.AvgTimeToMerge)
+		}
+	}
+}
+
+func TestReviewerStatsWindow(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	suffix := fmt.Sprintf("%d", rnd.Int())
+
+	teamName := "team_stats_window_" + suffix
+	users := []string{"sw1_" + suffix, "sw2_" + suffix, "sw3_" + suffix}
+	members := make([]TeamMember, len(users))
+	for i, u := range users {
+		members[i] = TeamMember{UserID: u, Username: "User", IsActive: true}
+	}
+	createTeam(t, Team{TeamName: teamName, Members: members})
+
+	prID := "pr_stats_window_" + suffix
+	prResp := createPR(t, PullRequestReq{PRID: prID, PRName: "Window", AuthorID: users[0], ReviewersCount: 1})
+	require.Len(t, prResp.PR.Reviewers, 1)
+	oldReviewer := prResp.PR.Reviewers[0]
+
+	// the assignment is before the boundary, the reassignment after it
+	time.Sleep(time.Second)
+	boundary := time.Now().UTC().Format(time.RFC3339Nano)
+	time.Sleep(time.Second)
+	reassignResp := reassignPR(t, ReassignReq{PRID: prID, OldUserID: oldReviewer})
+
+	type counts struct{ assigned, reassignedAway int }
+	stats := func(query string) map[string]counts {
+		resp, err := client.Get(baseURL + "/stats/reviewers?team_name=" + teamName + "&" + query)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result struct {
+			Reviewers []struct {
+				UserID         string `json:"user_id"`
+				Assigned       int    `json:"assigned"`
+				ReassignedAway int    `json:"reassigned_away"`
+			} `json:"reviewers"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		byUser := map[string]counts{}
+		for _, r := range result.Reviewers {
+			byUser[r.UserID] = counts{r.Assigned, r.ReassignedAway}
+		}
+		return byUser
+	}
+
+	before := stats("to=" + boundary)
+	require.Equal(t, counts{1, 0}, before[oldReviewer])
+	require.Equal(t, counts{0, 0}, before[reassignResp.ReplacedBy])
+
+	after := stats("from=" + boundary)
+	require.Equal(t, counts{0, 1}, after[oldReviewer])
+	require.Equal(t, counts{1, 0}, after[reassignResp.ReplacedBy])
+}
+
+func TestPRHistory(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	suffix := fmt.Sprintf("%d", rnd.Int())
+
+	teamName := "team_history_" + suffix
+	users := []string{"h1_" + suffix, "h2_" + suffix, "h3_" + suffix, "h4_" + suffix}
+	members := make([]TeamMember, len(users))
+	for i, u := range users {
+		members[i] = TeamMember{UserID: u, Username: "User", IsActive: true}
+	}
+	createTeam(t, Team{TeamName: teamName, Members: members})
+
+	prID := "pr_history_" + suffix
+	prResp := createPR(t, PullRequestReq{PRID: prID, PRName: "History", AuthorID: users[0]})
+	oldReviewer := prResp.PR.Reviewers[0]
+
+	status := postJSON(t, "/pullRequest/reassign", map[string]any{
+		"pull_request_id": prID,
+		"old_user_id":     oldReviewer,
+		"actor_id":        users[0],
+		"reason":          "on vacation",
+	})
+	require.Equal(t, http.StatusOK, status)
+	mergePR(t, prID)
+
+	resp, err := client.Get(baseURL + "/pullRequest/history?pull_request_id=" + prID)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result struct {
+		Events []struct {
+			Type      string `json:"type"`
+			ActorID   string `json:"actor_id"`
+			OldUserID string `json:"old_user_id"`
+			Reason    string `json:"reason"`
+		} `json:"events"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+
+	types := make([]string, len(result.Events))
+	for i, e := range result.Events {
+		types[i] = e.Type
+	}
+	require.Equal(t, []string{"CREATED", "ASSIGNED", "ASSIGNED", "REASSIGNED", "MERGED"}, types)
+	reassigned := result.Events[3]
+	require.Equal(t, oldReviewer, reassigned.OldUserID)
+	require.Equal(t, users[0], reassigned.ActorID)
+	require.Equal(t, "on vacation", reassigned.Reason)
+}
+
+func TestWebhookCRUD(t *testing.T) {
+	status := postJSON(t, "/webhooks", map[string]any{
+		"url":    "ftp://example.com/hook",
+		"events": []string{"pr.created"},
+	})
+	require.Equal(t, http.StatusBadRequest, status)
+
+	body, err := json.Marshal(map[string]any{
+		"url":    "http://example.com/hook",
+		"events": []string{"pr.created", "pr.merged"},
+	})
+	require.NoError(t, err)
+	resp, err := client.Post(baseURL+"/webhooks", "application/json", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created struct {
+		Webhook struct {
+			ID     int64    `json:"webhook_id"`
+			Events []string `json:"events"`
+			Secret string   `json:"secret"`
+		} `json:"webhook"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.NotEmpty(t, created.Webhook.Secret)
+	require.Equal(t, []string{"pr.created", "pr.merged"}, created.Webhook.Events)
+
+	url := fmt.Sprintf("%s/webhooks?webhook_id=%d", baseURL, created.Webhook.ID)
+	req, err := http.NewRequest(http.MethodDelete, url, nil)
+	require.NoError(t, err)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	req, err = http.NewRequest(http.MethodDelete, url, nil)
+	require.NoError(t, err)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestCodeownersReviewer(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	suffix := fmt.Sprintf("%d", rnd.Int())
+
+	users := []string{"co1_" + suffix, "co2_" + suffix, "co3_" + suffix, "co4_" + suffix}
+	members := make([]TeamMember, len(users))
+	for i, u := range users {
+		members[i] = TeamMember{UserID: u, Username: "User", IsActive: true}
+	}
+	createTeam(t, Team{TeamName: "team_code_" + suffix, Members: members})
+
+	owner := "docs_owner_" + suffix
+	createTeam(t, Team{TeamName: "team_docs_" + suffix, Members: []TeamMember{
+		{UserID: owner, Username: "Docs", IsActive: true},
+	}})
+	// a second owner in another team, only reachable through CODEOWNERS
+	backupOwner := "docs_backup_" + suffix
+	createTeam(t, Team{TeamName: "team_docs_backup_" + suffix, Members: []TeamMember{
+		{UserID: backupOwner, Username: "Docs", IsActive: true},
+	}})
+	owners := []string{owner, backupOwner}
+
+	repo := "octo-org/repo_" + suffix
+	status := postJSON(t, "/repositories/setCodeowners", map[string]any{
+		"repository": repo,
+		"content":    "* @" + users[1] + "\n/docs/ @" + owner + " @" + backupOwner + "\n",
+	})
+	require.Equal(t, http.StatusOK, status)
+
+	status = postJSON(t, "/repositories/setCodeowners", map[string]any{
+		"repository": repo,
+		"content":    "!docs/ @" + owner,
+	})
+	require.Equal(t, http.StatusBadRequest, status)
+
+	for i := 0; i < 3; i++ {
+		prResp := createPR(t, PullRequestReq{
+			PRID:         fmt.Sprintf("pr_code_%d_%s", i, suffix),
+			PRName:       "Docs",
+			AuthorID:     users[0],
+			Repository:   repo,
+			ChangedFiles: []string{"docs/index.md"},
+		})
+		require.Len(t, prResp.PR.Reviewers, 2)
+		picked := slices.DeleteFunc(slices.Clone(prResp.PR.Reviewers), func(id string) bool {
+			return !slices.Contains(owners, id)
+		})
+		require.Len(t, picked, 1)
+
+		// replacing the owner pick keeps an owner on the PR
+		reassigned := reassignPR(t, ReassignReq{PRID: prResp.PR.ID, OldUserID: picked[0]})
+		require.NotContains(t, reassigned.PR.Reviewers, picked[0])
+		require.True(t, slices.ContainsFunc(reassigned.PR.Reviewers, func(id string) bool {
+			return slices.Contains(owners, id)
+		}))
+	}
+}
+
+func TestRepositoryReviewerPool(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	suffix := fmt.Sprintf("%d", rnd.Int())
+
+	author := "rp_author_" + suffix
+	createTeam(t, Team{TeamName: "team_rp_a_" + suffix, Members: []TeamMember{
+		{UserID: author, Username: "Author", IsActive: true},
+		{UserID: "rp_a2_" + suffix, Username: "User", IsActive: true},
+		{UserID: "rp_a3_" + suffix, Username: "User", IsActive: true},
+	}})
+	pool := []string{"rp_b1_" + suffix, "rp_b2_" + suffix}
+	createTeam(t, Team{TeamName: "team_rp_b_" + suffix, Members: []TeamMember{
+		{UserID: pool[0], Username: "User", IsActive: true},
+		{UserID: pool[1], Username: "User", IsActive: true},
+	}})
+
+	repo := "octo-org/pool_" + suffix
+	status := postJSON(t, "/repositories/add", map[string]any{
+		"repository_name":    repo,
+		"owning_teams":       []string{"team_rp_a_" + suffix},
+		"reviewers_required": 1,
+		"reviewer_pool":      pool,
+	})
+	require.Equal(t, http.StatusCreated, status)
+
+	status = postJSON(t, "/repositories/add", map[string]any{
+		"repository_name": repo,
+	})
+	require.Equal(t, http.StatusConflict, status)
+
+	prResp := createPR(t, PullRequestReq{PRID: "pr_pool_" + suffix, PRName: "Pool", AuthorID: author, Repository: repo})
+	require.Len(t, prResp.PR.Reviewers, 1)
+	require.Subset(t, pool, prResp.PR.Reviewers)
+
+	reassigned := reassignPR(t, ReassignReq{PRID: "pr_pool_" + suffix, OldUserID: prResp.PR.Reviewers[0]})
+	require.Subset(t, pool, reassigned.PR.Reviewers)
+
+	status = postJSON(t, "/pullRequest/create", map[string]any{
+		"pull_request_id":   "pr_pool_missing_" + suffix,
+		"pull_request_name": "Missing",
+		"author_id":         author,
+		"repository":        "octo-org/missing_" + suffix,
+	})
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestCrossTeamReviewRule(t *testing.T) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	suffix := fmt.Sprintf("%d", rnd.Int())
+
+	author := "rr_author_" + suffix
+	teamName := "team_rr_dev_" + suffix
+	createTeam(t, Team{TeamName: teamName, ReviewersRequired: 1, Members: []TeamMember{
+		{UserID: author, Username: "Author", IsActive: true},
+		{UserID: "rr_dev2_" + suffix, Username: "User", IsActive: true},
+	}})
+	security := []string{"rr_sec1_" + suffix, "rr_sec2_" + suffix, "rr_sec3_" + suffix}
+	securityTeam := "team_rr_sec_" + suffix
+	createTeam(t, Team{TeamName: securityTeam, Members: []TeamMember{
+		{UserID: security[0], Username: "User", IsActive: true},
+		{UserID: security[1], Username: "User", IsActive: true},
+		{UserID: security[2], Username: "User", IsActive: true},
+	}})
+
+	status := postJSON(t, "/team/setReviewRule", map[string]any{
+		"team_name":     teamName,
+		"required_team": teamName,
+		"reviewers":     1,
+	})
+	require.Equal(t, http.StatusBadRequest, status)
+
+	status = postJSON(t, "/team/setReviewRule", map[string]any{
+		"team_name":     teamName,
+		"required_team": "team_rr_missing_" + suffix,
+		"reviewers":     1,
+	})
+	require.Equal(t, http.StatusNotFound, status)
+
+	status = postJSON(t, "/team/setReviewRule", map[string]any{
+		"team_name":     teamName,
+		"required_team": securityTeam,
+		"reviewers":     1,
+	})
+	require.Equal(t, http.StatusOK, status)
+
+	prResp := createPR(t, PullRequestReq{PRID: "pr_rule_" + suffix, PRName: "Rule", AuthorID: author})
+	require.Len(t, prResp.PR.Reviewers, 2)
+	require.Contains(t, prResp.PR.Reviewers, "rr_dev2_"+suffix)
+
+	var securityReviewer string
+	for _, id := range prResp.PR.Reviewers {
+		if slices.Contains(security, id) {
+			securityReviewer = id
+		}
+	}
+	require.NotEmpty(t, securityReviewer, "expected a reviewer from the security team")
+
+	reassigned := reassignPR(t, ReassignReq{PRID: "pr_rule_" + suffix, OldUserID: securityReviewer})
+	require.Contains(t, security, reassigned.ReplacedBy)
+	require.NotEqual(t, securityReviewer, reassigned.ReplacedBy)
+
+	status = sendReview(t, ReviewReq{PRID: "pr_rule_" + suffix, ReviewerID: "rr_dev2_" + suffix, State: "APPROVED"})
+	require.Equal(t, http.StatusOK, status)
+	_, status = sendMergeRequest(t, "pr_rule_"+suffix)
+	require.Equal(t, http.StatusConflict, status, "The security reviewer has not approved yet")
+
+	status = sendReview(t, ReviewReq{PRID: "pr_rule_" + suffix, ReviewerID: reassigned.ReplacedBy, State: "APPROVED"})
+	require.Equal(t, http.StatusOK, status)
+	mergePR(t, "pr_rule_"+suffix)
+
+	// the security team has only three members
+	status = postJSON(t, "/team/setReviewRule", map[string]any{
+		"team_name":     teamName,
+		"required_team": securityTeam,
+		"reviewers":     4,
+	})
+	require.Equal(t, http.StatusOK, status)
+	status = postJSON(t, "/pullRequest/create", map[string]any{
+		"pull_request_id":   "pr_rule_short_" + suffix,
+		"pull_request_name": "Short",
+		"author_id":         author,
+	})
+	require.Equal(t, http.StatusConflict, status)
+
+	status = postJSON(t, "/team/removeReviewRule", map[string]any{
+		"team_name":     teamName,
+		"required_team": securityTeam,
+	})
+	require.Equal(t, http.StatusOK, status)
+
+	prResp = createPR(t, PullRequestReq{PRID: "pr_rule_removed_" + suffix, PRName: "No rule", AuthorID: author})
+	require.Equal(t, []string{"rr_dev2_" + suffix}, prResp.PR.Reviewers)
+}
+
+func postJSON(t *testing.T, path string, payload any) int {
+	body, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	resp, err := client.Post(baseURL+path, "application/json", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	return resp.StatusCode
+}
+
 func createTeam(t *testing.T, team Team) {
 	body, err := json.Marshal(team)
 	require.NoError(t, err)
@@ -243,4 +1262,54 @@ func sendMergeRequest(t *testing.T, prID string) (PullRequestResp, int) {
 		require.NoError(t, err)
 	}
 	return result, resp.StatusCode
-}
\ No newline at end of file
+}
+
+func sendReview(t *testing.T, payload ReviewReq) int {
+	body, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, baseURL+"/pullRequest/review", bytes.NewBuffer(body))
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+func getReview(t *testing.T, userID string) GetReviewResp {
+	return getReviewPage(t, "user_id="+userID)
+}
+
+func getReviewPage(t *testing.T, query string) GetReviewResp {
+	resp, err := client.Get(baseURL + "/users/getReview?" + query)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result GetReviewResp
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	require.NoError(t, err)
+	return result
+}
+
+func sendStatusChange(t *testing.T, path, prID string) (PullRequestResp, int) {
+	body, err := json.Marshal(MergeReq{PRID: prID})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, baseURL+path, bytes.NewBuffer(body))
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var result PullRequestResp
+	if resp.StatusCode == http.StatusOK {
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		require.NoError(t, err)
+	}
+	return result, resp.StatusCode
+}